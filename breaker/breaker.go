@@ -0,0 +1,147 @@
+// Package breaker implements a Google-SRE-style adaptive throttling
+// circuit breaker, so a degraded backend is shed instead of piling up
+// requests on an already-struggling connection pool.
+package breaker
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned when a call is rejected by the breaker.
+var ErrBreakerOpen = errors.New("breaker: open, rejecting call")
+
+// Config controls the rolling window and rejection aggressiveness.
+type Config struct {
+	// Window is the total duration tracked, split into Buckets slices.
+	// Defaults to 10s.
+	Window time.Duration
+	// Buckets is how many slices Window is split into. Defaults to 40.
+	Buckets int
+	// K controls how aggressively the breaker rejects: rejection starts
+	// once requests exceed K times the accepts, so any K>1 keeps a margin
+	// and the breaker doesn't open while calls succeed. Lower values reject
+	// sooner. Defaults to 1.5.
+	K float64
+}
+
+func (c Config) withDefaults() Config {
+	if c.Window <= 0 {
+		c.Window = 10 * time.Second
+	}
+	if c.Buckets <= 0 {
+		c.Buckets = 40
+	}
+	if c.K <= 0 {
+		c.K = 1.5
+	}
+
+	// Window/Buckets is an integer time.Duration division: if Buckets is
+	// large enough relative to Window it truncates to 0, and rotate's
+	// `for now.Sub(...) >= b.bucketDur` loop never terminates. Cap Buckets
+	// so each bucket covers at least 1ns of the window.
+	if maxBuckets := int(c.Window / time.Nanosecond); c.Buckets > maxBuckets {
+		c.Buckets = maxBuckets
+	}
+
+	return c
+}
+
+type bucket struct {
+	requests int64
+	accepts  int64
+	start    time.Time
+}
+
+// Breaker tracks a rolling window of requests/accepts and probabilistically
+// rejects calls once requests exceed K times the accepts, per
+// https://sre.google/sre-book/handling-overload/#eq2101.
+type Breaker struct {
+	cfg       Config
+	bucketDur time.Duration
+
+	mu      sync.Mutex
+	buckets []bucket
+	cur     int
+}
+
+// New creates a Breaker for the given config.
+func New(cfg Config) *Breaker {
+	cfg = cfg.withDefaults()
+	b := &Breaker{
+		cfg:       cfg,
+		bucketDur: cfg.Window / time.Duration(cfg.Buckets),
+		buckets:   make([]bucket, cfg.Buckets),
+	}
+	b.buckets[0].start = time.Now()
+	return b
+}
+
+// Allow reports whether a call should proceed, rejecting with
+// ErrBreakerOpen with probability p = max(0, (requests-K*accepts)/(requests+1)).
+// When a call is allowed, the caller must report its outcome via Success or
+// Failure so the window reflects what actually happened; a rejected call is
+// not reported.
+func (b *Breaker) Allow() error {
+	b.rotate()
+
+	b.mu.Lock()
+	var requests, accepts int64
+	for i := range b.buckets {
+		requests += b.buckets[i].requests
+		accepts += b.buckets[i].accepts
+	}
+	b.mu.Unlock()
+
+	p := math.Max(0, (float64(requests)-b.cfg.K*float64(accepts))/(float64(requests)+1))
+	if p > 0 && rand.Float64() < p {
+		return ErrBreakerOpen
+	}
+	return nil
+}
+
+// Success records an accepted, successful call.
+func (b *Breaker) Success() {
+	b.rotate()
+	b.mu.Lock()
+	b.buckets[b.cur].requests++
+	b.buckets[b.cur].accepts++
+	b.mu.Unlock()
+}
+
+// Failure records a call that was attempted but failed; it still counts
+// against "requests" so the breaker opens under real backend errors, not
+// just client-side rejections.
+func (b *Breaker) Failure() {
+	b.rotate()
+	b.mu.Lock()
+	b.buckets[b.cur].requests++
+	b.mu.Unlock()
+}
+
+// rotate advances the current bucket (clearing stale ones) as time passes,
+// so the window only ever reflects the last cfg.Window of activity.
+func (b *Breaker) rotate() {
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// idle for a whole window or more: every bucket is stale
+	if now.Sub(b.buckets[b.cur].start) >= b.cfg.Window {
+		for i := range b.buckets {
+			b.buckets[i] = bucket{}
+		}
+		b.buckets[b.cur].start = now
+		return
+	}
+
+	for now.Sub(b.buckets[b.cur].start) >= b.bucketDur {
+		next := b.buckets[b.cur].start.Add(b.bucketDur)
+		b.cur = (b.cur + 1) % len(b.buckets)
+		b.buckets[b.cur] = bucket{start: next}
+	}
+}
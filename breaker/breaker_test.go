@@ -0,0 +1,37 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNew_PathologicalBucketsDoesNotHang guards against bucketDur
+// truncating to 0 when Buckets is large relative to Window (an integer
+// time.Duration division): rotate's `for now.Sub(...) >= b.bucketDur` loop
+// would then never terminate, spinning forever while holding b.mu and
+// locking up every call the breaker guards.
+func TestNew_PathologicalBucketsDoesNotHang(t *testing.T) {
+	b := New(Config{Window: time.Nanosecond, Buckets: 1 << 30})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, b.Allow())
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Allow did not return: rotate appears to be stuck in an infinite loop")
+	}
+}
+
+// TestConfig_WithDefaults_FloorsBucketDuration guards the fix directly:
+// whatever Buckets is requested, the resulting bucketDur must never be 0.
+func TestConfig_WithDefaults_FloorsBucketDuration(t *testing.T) {
+	cfg := Config{Window: time.Nanosecond, Buckets: 1000}.withDefaults()
+	bucketDur := cfg.Window / time.Duration(cfg.Buckets)
+	assert.True(t, bucketDur > 0)
+}
@@ -0,0 +1,155 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spiral/kv"
+)
+
+// StorageConfig configures the Storage wrapper: the rolling window used by
+// the underlying Breaker, plus which methods it guards. A nil/empty
+// Methods set guards every method.
+type StorageConfig struct {
+	Config
+	// Methods lists the Storage methods to protect, e.g.
+	// {"Get": true, "Set": true}. Unlisted methods bypass the breaker.
+	// Nil or empty means "guard everything".
+	Methods map[string]bool
+}
+
+// Storage wraps a kv.Storage with a circuit breaker, so once the backend
+// degrades, guarded calls fail fast with ErrBreakerOpen instead of piling
+// up on the connection pool. Any driver can be wrapped this way, not just
+// redis.
+type Storage struct {
+	kv.Storage
+	breaker *Breaker
+	guard   map[string]bool
+}
+
+// Wrap returns a Storage that guards s's calls per cfg.
+func Wrap(s kv.Storage, cfg StorageConfig) *Storage {
+	return &Storage{
+		Storage: s,
+		breaker: New(cfg.Config),
+		guard:   cfg.Methods,
+	}
+}
+
+func (s *Storage) protects(method string) bool {
+	return len(s.guard) == 0 || s.guard[method]
+}
+
+func (s *Storage) Has(ctx context.Context, keys ...string) (map[string]bool, error) {
+	if !s.protects("Has") {
+		return s.Storage.Has(ctx, keys...)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	res, err := s.Storage.Has(ctx, keys...)
+	s.report(err)
+	return res, err
+}
+
+func (s *Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	if !s.protects("Get") {
+		return s.Storage.Get(ctx, key)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	res, err := s.Storage.Get(ctx, key)
+	s.report(err)
+	return res, err
+}
+
+func (s *Storage) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if !s.protects("MGet") {
+		return s.Storage.MGet(ctx, keys...)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	res, err := s.Storage.MGet(ctx, keys...)
+	s.report(err)
+	return res, err
+}
+
+func (s *Storage) Set(ctx context.Context, items ...kv.Item) error {
+	if !s.protects("Set") {
+		return s.Storage.Set(ctx, items...)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	err := s.Storage.Set(ctx, items...)
+	s.report(err)
+	return err
+}
+
+func (s *Storage) MExpire(ctx context.Context, timeout int, keys ...string) error {
+	if !s.protects("MExpire") {
+		return s.Storage.MExpire(ctx, timeout, keys...)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	err := s.Storage.MExpire(ctx, timeout, keys...)
+	s.report(err)
+	return err
+}
+
+func (s *Storage) TTL(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	if !s.protects("TTL") {
+		return s.Storage.TTL(ctx, keys...)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	res, err := s.Storage.TTL(ctx, keys...)
+	s.report(err)
+	return res, err
+}
+
+func (s *Storage) Delete(ctx context.Context, keys ...string) error {
+	if !s.protects("Delete") {
+		return s.Storage.Delete(ctx, keys...)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	err := s.Storage.Delete(ctx, keys...)
+	s.report(err)
+	return err
+}
+
+// report feeds the call outcome back into the breaker. A rejected call
+// never reaches here since Allow already returned before the underlying
+// call was made.
+//
+// A validation error (bad caller input, e.g. kv.ErrEmptyKey/kv.ErrNoKeys)
+// means the backend was never actually exercised, so it's neither a
+// Success nor a Failure — reporting it either way would let a client
+// sending bad input trip the breaker for every other caller of an
+// otherwise-healthy backend.
+func (s *Storage) report(err error) {
+	if isValidationError(err) {
+		return
+	}
+	if err != nil {
+		s.breaker.Failure()
+		return
+	}
+	s.breaker.Success()
+}
+
+// isValidationError reports whether err reflects bad caller input rather
+// than a backend failure.
+func isValidationError(err error) bool {
+	return errors.Is(err, kv.ErrNoKeys) ||
+		errors.Is(err, kv.ErrEmptyKey) ||
+		errors.Is(err, kv.ErrNoConfig) ||
+		errors.Is(err, kv.ErrEmptyItem)
+}
@@ -0,0 +1,82 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spiral/kv"
+	"github.com/stretchr/testify/assert"
+)
+
+// erroringStorage is a kv.Storage stub that always fails Get with a fixed
+// error, so report's classification of that error can be exercised
+// directly.
+type erroringStorage struct {
+	err error
+}
+
+func (f *erroringStorage) Has(_ context.Context, _ ...string) (map[string]bool, error) {
+	return nil, f.err
+}
+
+func (f *erroringStorage) Get(_ context.Context, _ string) ([]byte, error) {
+	return nil, f.err
+}
+
+func (f *erroringStorage) MGet(_ context.Context, _ ...string) ([]interface{}, error) {
+	return nil, f.err
+}
+
+func (f *erroringStorage) Set(_ context.Context, _ ...kv.Item) error {
+	return f.err
+}
+
+func (f *erroringStorage) MExpire(_ context.Context, _ int, _ ...string) error {
+	return f.err
+}
+
+func (f *erroringStorage) TTL(_ context.Context, _ ...string) (map[string]interface{}, error) {
+	return nil, f.err
+}
+
+func (f *erroringStorage) Delete(_ context.Context, _ ...string) error {
+	return f.err
+}
+
+func (f *erroringStorage) Close() error {
+	return nil
+}
+
+// TestReport_ValidationErrorDoesNotOpenBreaker guards against caller
+// input errors (kv.ErrEmptyKey) being forwarded into Failure(), which
+// would let a client sending bad input trip the breaker for every other
+// caller of an otherwise-healthy backend.
+func TestReport_ValidationErrorDoesNotOpenBreaker(t *testing.T) {
+	s := Wrap(&erroringStorage{err: kv.ErrEmptyKey}, StorageConfig{})
+
+	for i := 0; i < 1000; i++ {
+		_, err := s.Get(context.Background(), "")
+		assert.ErrorIs(t, err, kv.ErrEmptyKey)
+	}
+
+	assert.NoError(t, s.breaker.Allow())
+}
+
+// TestReport_BackendErrorOpensBreaker is the control: a real backend
+// failure still counts against the breaker.
+func TestReport_BackendErrorOpensBreaker(t *testing.T) {
+	backendErr := errors.New("connection refused")
+	s := Wrap(&erroringStorage{err: backendErr}, StorageConfig{})
+
+	rejected := false
+	for i := 0; i < 1000; i++ {
+		if err := s.breaker.Allow(); err != nil {
+			rejected = true
+			break
+		}
+		_, _ = s.Get(context.Background(), "key")
+	}
+
+	assert.True(t, rejected, "breaker never opened despite repeated backend failures")
+}
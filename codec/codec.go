@@ -0,0 +1,211 @@
+// Package codec provides a pluggable value codec on top of kv.Storage so
+// callers can store typed Go values directly instead of serializing by
+// hand for every call.
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes and decodes Go values to/from the byte representation
+// stored by a kv.Storage.
+type Codec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+// JSON returns a Codec backed by encoding/json.
+func JSON() Codec { return jsonCodec{} }
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+// Gob returns a Codec backed by encoding/gob.
+func Gob() Codec { return gobCodec{} }
+
+func (gobCodec) Encode(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Decode(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type msgpackCodec struct{}
+
+// MessagePack returns a Codec backed by vmihailenco/msgpack, a more compact
+// wire format than JSON for the same struct.
+func MessagePack() Codec { return msgpackCodec{} }
+
+func (msgpackCodec) Encode(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Decode(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+// errNotProtoMessage is returned when Protobuf's Encode/Decode is given a
+// value that doesn't implement proto.Message.
+var errNotProtoMessage = errors.New("kv/codec: value does not implement proto.Message")
+
+type protobufCodec struct{}
+
+// Protobuf returns a Codec backed by google.golang.org/protobuf. Unlike the
+// other codecs, it only accepts values implementing proto.Message, since
+// that's what carries the wire schema protobuf needs.
+func Protobuf() Codec { return protobufCodec{} }
+
+func (protobufCodec) Encode(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, errNotProtoMessage
+	}
+	return proto.Marshal(msg)
+}
+
+func (protobufCodec) Decode(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return errNotProtoMessage
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// gzipCodec transparently gzips the output of another Codec.
+type gzipCodec struct {
+	codec Codec
+}
+
+// Gzip wraps codec so every encoded value is gzip-compressed, and every
+// decoded value is gunzipped first. Useful for large JSON/gob payloads
+// where the compression ratio outweighs the CPU cost.
+func Gzip(codec Codec) Codec {
+	return gzipCodec{codec: codec}
+}
+
+func (g gzipCodec) Encode(v interface{}) ([]byte, error) {
+	raw, err := g.codec.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (g gzipCodec) Decode(data []byte, v interface{}) error {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return g.codec.Decode(raw, v)
+}
+
+// snappyCodec transparently snappy-compresses the output of another Codec.
+type snappyCodec struct {
+	codec Codec
+}
+
+// Snappy wraps codec so every encoded value is snappy-compressed, and
+// every decoded value is decompressed first. Lower compression ratio than
+// Gzip/Zstd but much cheaper CPU-wise, for latency-sensitive callers.
+func Snappy(codec Codec) Codec {
+	return snappyCodec{codec: codec}
+}
+
+func (s snappyCodec) Encode(v interface{}) ([]byte, error) {
+	raw, err := s.codec.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, raw), nil
+}
+
+func (s snappyCodec) Decode(data []byte, v interface{}) error {
+	raw, err := snappy.Decode(nil, data)
+	if err != nil {
+		return err
+	}
+	return s.codec.Decode(raw, v)
+}
+
+// zstdCodec transparently zstd-compresses the output of another Codec.
+//
+// The encoder/decoder are built once, in Zstd, and reused across every
+// Encode/Decode call: per klauspost/compress's own docs they're expensive
+// to construct (each spins up background worker goroutines), and both
+// types are documented safe for concurrent use.
+type zstdCodec struct {
+	codec Codec
+	enc   *zstd.Encoder
+	dec   *zstd.Decoder
+}
+
+// Zstd wraps codec so every encoded value is zstd-compressed, and every
+// decoded value is decompressed first. Best compression ratio of the
+// three, at the highest CPU cost; a good default for large, rarely-hot
+// payloads.
+func Zstd(codec Codec) Codec {
+	// NewWriter/NewReader only error on invalid EOption/DOption values;
+	// called with none here, so the error is always nil.
+	enc, _ := zstd.NewWriter(nil)
+	dec, _ := zstd.NewReader(nil)
+	return zstdCodec{codec: codec, enc: enc, dec: dec}
+}
+
+func (z zstdCodec) Encode(v interface{}) ([]byte, error) {
+	raw, err := z.codec.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return z.enc.EncodeAll(raw, nil), nil
+}
+
+func (z zstdCodec) Decode(data []byte, v interface{}) error {
+	raw, err := z.dec.DecodeAll(data, nil)
+	if err != nil {
+		return err
+	}
+
+	return z.codec.Decode(raw, v)
+}
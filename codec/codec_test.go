@@ -0,0 +1,75 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type codecTestValue struct {
+	Name string
+	Age  int
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	codecs := map[string]Codec{
+		"json":          JSON(),
+		"gob":           Gob(),
+		"msgpack":       MessagePack(),
+		"gzip(json)":    Gzip(JSON()),
+		"snappy(json)":  Snappy(JSON()),
+		"zstd(json)":    Zstd(JSON()),
+		"gzip(msgpack)": Gzip(MessagePack()),
+		"zstd(gob)":     Zstd(Gob()),
+		"snappy(gob)":   Snappy(Gob()),
+		"gzip(snappy)":  Gzip(Snappy(JSON())),
+	}
+
+	for name, c := range codecs {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			in := codecTestValue{Name: "alice", Age: 30}
+
+			data, err := c.Encode(in)
+			assert.NoError(t, err)
+
+			var out codecTestValue
+			assert.NoError(t, c.Decode(data, &out))
+			assert.Equal(t, in, out)
+		})
+	}
+}
+
+// TestZstd_ReusesEncoderDecoder guards against Zstd building a new
+// zstd.Encoder/Decoder on every Encode/Decode call: those are expensive to
+// construct, so a single Codec value must keep reusing the same ones.
+func TestZstd_ReusesEncoderDecoder(t *testing.T) {
+	c := Zstd(JSON()).(zstdCodec)
+	enc, dec := c.enc, c.dec
+
+	for i := 0; i < 3; i++ {
+		data, err := c.Encode(codecTestValue{Name: "alice", Age: 30})
+		assert.NoError(t, err)
+
+		var out codecTestValue
+		assert.NoError(t, c.Decode(data, &out))
+		assert.Equal(t, "alice", out.Name)
+	}
+
+	assert.Same(t, enc, c.enc)
+	assert.Same(t, dec, c.dec)
+}
+
+// TestProtobuf_RequiresProtoMessage guards against Protobuf silently
+// accepting (and mis-encoding) a plain struct: protobuf needs a generated
+// proto.Message to carry its wire schema, so anything else must error
+// instead of producing garbage bytes.
+func TestProtobuf_RequiresProtoMessage(t *testing.T) {
+	c := Protobuf()
+
+	_, err := c.Encode(codecTestValue{Name: "alice"})
+	assert.ErrorIs(t, err, errNotProtoMessage)
+
+	err = c.Decode([]byte("irrelevant"), &codecTestValue{})
+	assert.ErrorIs(t, err, errNotProtoMessage)
+}
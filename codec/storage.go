@@ -0,0 +1,49 @@
+package codec
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spiral/kv"
+)
+
+// ErrNotFound is returned by GetTyped when the key has no value.
+var ErrNotFound = errors.New("kv/codec: key not found")
+
+// Storage wraps a kv.Storage with a Codec, adding SetTyped/GetTyped so
+// callers can store structs directly instead of serializing by hand. All
+// other kv.Storage methods pass through to the wrapped storage unchanged.
+type Storage struct {
+	kv.Storage
+	codec Codec
+}
+
+// Wrap returns a Storage that encodes/decodes typed values with codec
+// before delegating to s.
+func Wrap(s kv.Storage, codec Codec) *Storage {
+	return &Storage{Storage: s, codec: codec}
+}
+
+// SetTyped encodes v with the configured codec and stores it under key,
+// with the same TTL semantics as Storage.Set (0 means no TTL).
+func (s *Storage) SetTyped(ctx context.Context, key string, v interface{}, ttl int) error {
+	data, err := s.codec.Encode(v)
+	if err != nil {
+		return err
+	}
+
+	return s.Set(ctx, kv.Item{Key: key, Value: string(data), TTL: ttl})
+}
+
+// GetTyped loads key and decodes it into out using the configured codec.
+func (s *Storage) GetTyped(ctx context.Context, key string, out interface{}) error {
+	data, err := s.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return ErrNotFound
+	}
+
+	return s.codec.Decode(data, out)
+}
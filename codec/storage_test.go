@@ -0,0 +1,91 @@
+package codec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiral/kv"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStorage is a minimal in-memory kv.Storage stand-in, so SetTyped/
+// GetTyped can be tested without a real driver.
+type fakeStorage struct {
+	values map[string]string
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{values: map[string]string{}}
+}
+
+func (f *fakeStorage) Has(_ context.Context, keys ...string) (map[string]bool, error) {
+	m := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		_, ok := f.values[key]
+		m[key] = ok
+	}
+	return m, nil
+}
+
+func (f *fakeStorage) Get(_ context.Context, key string) ([]byte, error) {
+	if v, ok := f.values[key]; ok {
+		return []byte(v), nil
+	}
+	return nil, nil
+}
+
+func (f *fakeStorage) MGet(_ context.Context, keys ...string) ([]interface{}, error) {
+	res := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if v, ok := f.values[key]; ok {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeStorage) Set(_ context.Context, items ...kv.Item) error {
+	for _, item := range items {
+		f.values[item.Key] = item.Value
+	}
+	return nil
+}
+
+func (f *fakeStorage) MExpire(_ context.Context, _ int, _ ...string) error {
+	return nil
+}
+
+func (f *fakeStorage) TTL(_ context.Context, _ ...string) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (f *fakeStorage) Delete(_ context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(f.values, key)
+	}
+	return nil
+}
+
+func (f *fakeStorage) Close() error {
+	return nil
+}
+
+func TestStorage_SetTyped_GetTyped(t *testing.T) {
+	s := Wrap(newFakeStorage(), JSON())
+	ctx := context.Background()
+
+	in := codecTestValue{Name: "alice", Age: 30}
+	assert.NoError(t, s.SetTyped(ctx, "key", in, 0))
+
+	var out codecTestValue
+	assert.NoError(t, s.GetTyped(ctx, "key", &out))
+	assert.Equal(t, in, out)
+}
+
+func TestStorage_GetTyped_NotFound(t *testing.T) {
+	s := Wrap(newFakeStorage(), JSON())
+
+	var out codecTestValue
+	err := s.GetTyped(context.Background(), "missing", &out)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
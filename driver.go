@@ -48,4 +48,76 @@ type Storage interface {
 
 	// Close closes the storage and underlying resources.
 	Close() error
+}
+
+// Iterator walks over an ordered range of key/value pairs, modeled on the
+// cursor-style iterators exposed by LevelDB/Tendermint's db.Iterator. The
+// usual pattern is:
+//
+//	it, err := storage.Iterator(ctx, start, end)
+//	for ; it.Valid(); it.Next() {
+//		use(it.Key(), it.Value())
+//	}
+//	it.Close()
+type Iterator interface {
+	// Valid returns false once the iterator has been exhausted or an
+	// error has occurred; Key/Value/Next must not be called afterwards.
+	Valid() bool
+
+	// Next advances the iterator to the following key.
+	Next()
+
+	// Key returns the key at the current position.
+	Key() []byte
+
+	// Value returns the value at the current position.
+	Value() []byte
+
+	// Error returns the first error encountered during iteration, if any.
+	Error() error
+
+	// Close releases resources held by the iterator.
+	Close() error
+}
+
+// Iterable is implemented by drivers able to produce ordered range scans.
+// It's kept separate from Storage because not every backend has a notion
+// of key order (e.g. memcached), so drivers opt in by implementing it.
+type Iterable interface {
+	// Iterator returns an iterator over the half-open range [start, end)
+	// in ascending key order. A nil start/end means "from the first key"
+	// / "to the last key" respectively.
+	Iterator(ctx context.Context, start, end []byte) (Iterator, error)
+
+	// ReverseIterator is like Iterator but walks keys in descending order.
+	ReverseIterator(ctx context.Context, start, end []byte) (Iterator, error)
+}
+
+// Batch accumulates mutations to be applied atomically by Commit. Unlike
+// Set(ctx, items...), which drivers may apply key-by-key, everything staged
+// on a Batch either lands together or not at all (a single bbolt Update
+// transaction, a Redis MULTI/EXEC pipeline, ...). Batch methods return the
+// Batch itself so calls can be chained.
+type Batch interface {
+	// Set stages a key/value write with the given TTL (0 means no TTL).
+	Set(key string, value string, ttl int) Batch
+
+	// SetTTL stages a TTL update for an existing key.
+	SetTTL(key string, ttl int) Batch
+
+	// Delete stages a key removal.
+	Delete(key string) Batch
+
+	// Commit applies every staged mutation atomically.
+	Commit(ctx context.Context) error
+
+	// Discard abandons the batch; nothing staged on it is applied. Safe
+	// to call after Commit as a no-op cleanup in a defer.
+	Discard()
+}
+
+// Batcher is implemented by drivers able to apply a Batch atomically.
+type Batcher interface {
+	// Batch returns a new, empty Batch bound to this storage.
+	Batch() Batch
 }
\ No newline at end of file
@@ -0,0 +1,88 @@
+// Package bloom implements a small, dependency-free bloom filter used by
+// disk-backed kv drivers to short-circuit negative lookups (Has/MGet on a
+// missing key) without touching the underlying store.
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// Filter is a fixed-size bloom filter over byte-slice keys. It is safe for
+// concurrent reads; callers are expected to serialize writes (Add/Reset)
+// the same way they already serialize mutations on the owning storage.
+type Filter struct {
+	bits []uint64 // m bits packed 64 per word
+	m    uint64   // number of bits
+	k    uint64   // number of hash functions
+}
+
+// New sizes a filter for expectedItems entries at the given target
+// falsePositiveRate (e.g. 0.01 for 1%), using the standard
+// m = ceil(-n*ln(p) / ln(2)^2), k = ceil((m/n) * ln 2) formulas.
+func New(expectedItems uint64, falsePositiveRate float64) *Filter {
+	if expectedItems == 0 {
+		expectedItems = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	n := float64(expectedItems)
+	m := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m == 0 {
+		m = 1
+	}
+	k := uint64(math.Ceil((float64(m) / n) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+
+	return &Filter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// Add inserts key into the filter.
+func (f *Filter) Add(key []byte) {
+	h1, h2 := hashPair(key)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Test reports whether key may be present. false is a definitive "not
+// present"; true means "maybe present" (subject to the false-positive
+// rate the filter was sized for).
+func (f *Filter) Test(key []byte) bool {
+	h1, h2 := hashPair(key)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Reset clears every bit, e.g. before a Rebuild scan.
+func (f *Filter) Reset() {
+	for i := range f.bits {
+		f.bits[i] = 0
+	}
+}
+
+// hashPair derives two independent 64-bit hashes from key via double
+// hashing (h1 + i*h2 mod m), using FNV-1 and FNV-1a as the seed hashes.
+func hashPair(key []byte) (uint64, uint64) {
+	h1 := fnv.New64()
+	_, _ = h1.Write(key)
+
+	h2 := fnv.New64a()
+	_, _ = h2.Write(key)
+
+	return h1.Sum64(), h2.Sum64()
+}
@@ -0,0 +1,19 @@
+package boltdb
+
+// Config for the boltdb driver, used for RR integration.
+type Config struct {
+	// File is the path to the bbolt database file.
+	File string
+	// Permissions for the database file.
+	Permissions int
+	// Bucket to store the keys in.
+	Bucket string
+
+	// BloomExpectedItems is the anticipated number of keys in the bucket,
+	// used to size the in-memory bloom filter that fast-paths Has/MGet
+	// misses. Zero disables the filter.
+	BloomExpectedItems int
+	// BloomFalsePositiveRate is the target false-positive rate for the
+	// bloom filter, e.g. 0.01 for 1%. Defaults to 0.01 when unset.
+	BloomFalsePositiveRate float64
+}
@@ -0,0 +1,520 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spiral/kv"
+	"github.com/spiral/kv/driver/bloom"
+	bolt "go.etcd.io/bbolt"
+)
+
+// rebuildAfterDeletes is how many deleted keys the bloom filter tolerates
+// (it only ever sets bits, so deletes leave stale positives behind) before
+// Delete auto-triggers a Rebuild.
+const rebuildAfterDeletes = 1000
+
+// Storage is a boltdb-backed K/V storage. Every key/value pair lives in a
+// single bucket of a single bbolt file.
+type Storage struct {
+	db     *bolt.DB
+	bucket []byte
+
+	// filter short-circuits Has/MGet misses without touching the B+tree.
+	// nil when the driver wasn't configured with bloom sizing.
+	filter   *bloom.Filter
+	filterMu sync.RWMutex
+	deletes  int64
+
+	// wait group is used to prevent Serve for early exit
+	// is used together with stop
+	// BUT NOT USED IN GENERAL API, ONLY FOR RoadRunner
+	wg *sync.WaitGroup
+
+	// config for RR integration
+	cfg *Config
+}
+
+// NewBoltClient opens (creating if needed) a bbolt file at path and ensures
+// the given bucket exists.
+func NewBoltClient(path string, perm os.FileMode, options *bolt.Options, bucket string, gcTick time.Duration) (kv.Storage, error) {
+	db, err := bolt.Open(path, perm, options)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Storage{
+		db:     db,
+		bucket: []byte(bucket),
+	}
+
+	go s.gcPhase(gcTick)
+
+	return s, nil
+}
+
+func (s *Storage) Init(config *Config) (bool, error) {
+	if config == nil {
+		return false, kv.ErrNoConfig
+	}
+	s.cfg = config
+
+	if config.BloomExpectedItems > 0 {
+		if err := s.Rebuild(context.Background()); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (s *Storage) Has(ctx context.Context, keys ...string) (map[string]bool, error) {
+	if keys == nil {
+		return nil, kv.ErrNoKeys
+	}
+
+	m := make(map[string]bool, len(keys))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		for _, key := range keys {
+			keyTrimmed := strings.TrimSpace(key)
+			if keyTrimmed == "" {
+				return kv.ErrEmptyKey
+			}
+			if !s.maybePresent(key) {
+				m[key] = false
+				continue
+			}
+			m[key] = b.Get([]byte(key)) != nil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (s *Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	keyTrimmed := strings.TrimSpace(key)
+	if keyTrimmed == "" {
+		return nil, kv.ErrEmptyKey
+	}
+
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if v := b.Get([]byte(key)); v != nil {
+			// copy since v is only valid for the lifetime of the transaction
+			value = append([]byte{}, v...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (s *Storage) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if keys == nil {
+		return nil, kv.ErrNoKeys
+	}
+
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			return nil, kv.ErrEmptyKey
+		}
+	}
+
+	m := make([]interface{}, 0, len(keys))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		for _, key := range keys {
+			if !s.maybePresent(key) {
+				continue
+			}
+			if v := b.Get([]byte(key)); v != nil {
+				m = append(m, append([]byte{}, v...))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (s *Storage) Set(ctx context.Context, items ...kv.Item) error {
+	if items == nil {
+		return kv.ErrNoKeys
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		for _, item := range items {
+			if item.Key == "" {
+				return kv.ErrEmptyKey
+			}
+			if err := b.Put([]byte(item.Key), []byte(item.Value)); err != nil {
+				return err
+			}
+		}
+
+		// Added before Update returns (so before the writer lock is
+		// released) rather than after, so no reader can observe the
+		// tx committed without the filter knowing about its keys yet
+		// and get a false negative off the bloom fast-path.
+		s.filterMu.Lock()
+		if s.filter != nil {
+			for _, item := range items {
+				s.filter.Add([]byte(item.Key))
+			}
+		}
+		s.filterMu.Unlock()
+
+		return nil
+	})
+}
+
+// MExpire only validates its arguments: boltdb keeps no TTL metadata, so
+// no expiration is stored and the keys never expire.
+func (s *Storage) MExpire(ctx context.Context, timeout int, keys ...string) error {
+	if timeout == 0 || keys == nil {
+		return errors.New("should set timeout and at least one key")
+	}
+
+	return nil
+}
+
+// TTL is not supported for the boltdb driver.
+func (s *Storage) TTL(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	return nil, errors.New("not supported for the boltdb driver")
+}
+
+func (s *Storage) Delete(ctx context.Context, keys ...string) error {
+	if keys == nil {
+		return kv.ErrNoKeys
+	}
+
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			return kv.ErrEmptyKey
+		}
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		for _, key := range keys {
+			if err := b.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.filterMu.RLock()
+	hasFilter := s.filter != nil
+	s.filterMu.RUnlock()
+	if !hasFilter {
+		return nil
+	}
+
+	// the filter only ever sets bits, so a delete leaves a stale positive
+	// behind; once enough have piled up, rebuild from the bucket instead
+	// of letting the false-positive rate drift upward forever.
+	if atomic.AddInt64(&s.deletes, int64(len(keys))) > rebuildAfterDeletes {
+		atomic.StoreInt64(&s.deletes, 0)
+		return s.Rebuild(ctx)
+	}
+
+	return nil
+}
+
+// Rebuild re-scans the bucket and rebuilds the bloom filter from scratch.
+// Called automatically by Init (when bloom sizing is configured) and by
+// Delete once the deletion counter crosses rebuildAfterDeletes; callers may
+// also invoke it directly after a bulk load. A no-op that leaves the filter
+// disabled when cfg.BloomExpectedItems is 0, per its documented meaning.
+func (s *Storage) Rebuild(ctx context.Context) error {
+	cfg := s.cfg
+	if cfg == nil || cfg.BloomExpectedItems == 0 {
+		s.filterMu.Lock()
+		s.filter = nil
+		s.filterMu.Unlock()
+		return nil
+	}
+
+	fp := 0.01
+	if cfg.BloomFalsePositiveRate > 0 {
+		fp = cfg.BloomFalsePositiveRate
+	}
+
+	filter := bloom.New(uint64(cfg.BloomExpectedItems), fp)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).ForEach(func(k, _ []byte) error {
+			filter.Add(k)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.filterMu.Lock()
+	s.filter = filter
+	s.filterMu.Unlock()
+
+	return nil
+}
+
+// maybePresent consults the bloom filter, if any, to short-circuit an
+// on-disk lookup for a key that's definitely absent.
+func (s *Storage) maybePresent(key string) bool {
+	s.filterMu.RLock()
+	defer s.filterMu.RUnlock()
+
+	if s.filter == nil {
+		return true
+	}
+	return s.filter.Test([]byte(key))
+}
+
+// Iterator returns a cursor-backed iterator over [start, end) in ascending
+// key order. A nil start/end scans from the first/to the last key.
+func (s *Storage) Iterator(ctx context.Context, start, end []byte) (kv.Iterator, error) {
+	tx, err := s.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+
+	c := tx.Bucket(s.bucket).Cursor()
+	it := &boltIterator{tx: tx, cursor: c, start: start, end: end, reverse: false}
+	it.seekFirst()
+
+	return it, nil
+}
+
+// ReverseIterator is like Iterator but walks keys in descending order.
+func (s *Storage) ReverseIterator(ctx context.Context, start, end []byte) (kv.Iterator, error) {
+	tx, err := s.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+
+	c := tx.Bucket(s.bucket).Cursor()
+	it := &boltIterator{tx: tx, cursor: c, start: start, end: end, reverse: true}
+	it.seekFirst()
+
+	return it, nil
+}
+
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// WriteSync forces an fsync of any writes still buffered by bbolt (bbolt
+// syncs on every Commit by default, but callers running with NoSync for
+// throughput can use this to get a durability checkpoint on demand).
+func (s *Storage) WriteSync() error {
+	return s.db.Sync()
+}
+
+// Batch returns a Batch that applies every staged mutation inside a single
+// bbolt Update transaction, so the batch is all-or-nothing.
+func (s *Storage) Batch() kv.Batch {
+	return &boltBatch{storage: s}
+}
+
+//================================== PRIVATE ======================================
+
+// boltdb keeps no TTL metadata of its own (TTL/MExpire are no-ops above), so
+// the sweeper has nothing to do yet; it exists as the wiring point for when
+// TTL support lands.
+func (s *Storage) gcPhase(tick time.Duration) {
+	if tick <= 0 {
+		return
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for range ticker.C {
+	}
+}
+
+// boltIterator adapts a bbolt cursor, held inside its own read-only
+// transaction, to the kv.Iterator contract.
+type boltIterator struct {
+	tx      *bolt.Tx
+	cursor  *bolt.Cursor
+	start   []byte
+	end     []byte
+	reverse bool
+
+	key   []byte
+	value []byte
+	valid bool
+	err   error
+}
+
+func (it *boltIterator) seekFirst() {
+	var k, v []byte
+	if it.reverse {
+		if len(it.end) > 0 {
+			k, v = it.cursor.Seek(it.end)
+			if k == nil {
+				k, v = it.cursor.Last()
+			} else {
+				k, v = it.cursor.Prev()
+			}
+		} else {
+			k, v = it.cursor.Last()
+		}
+	} else {
+		if len(it.start) > 0 {
+			k, v = it.cursor.Seek(it.start)
+		} else {
+			k, v = it.cursor.First()
+		}
+	}
+	it.setPosition(k, v)
+}
+
+func (it *boltIterator) setPosition(k, v []byte) {
+	if k == nil {
+		it.valid = false
+		return
+	}
+	if !it.reverse && len(it.end) > 0 && bytes.Compare(k, it.end) >= 0 {
+		it.valid = false
+		return
+	}
+	if it.reverse && len(it.start) > 0 && bytes.Compare(k, it.start) < 0 {
+		it.valid = false
+		return
+	}
+	it.key = k
+	it.value = v
+	it.valid = true
+}
+
+func (it *boltIterator) Valid() bool {
+	return it.valid
+}
+
+func (it *boltIterator) Next() {
+	if !it.valid {
+		return
+	}
+	var k, v []byte
+	if it.reverse {
+		k, v = it.cursor.Prev()
+	} else {
+		k, v = it.cursor.Next()
+	}
+	it.setPosition(k, v)
+}
+
+func (it *boltIterator) Key() []byte {
+	return it.key
+}
+
+func (it *boltIterator) Value() []byte {
+	return it.value
+}
+
+func (it *boltIterator) Error() error {
+	return it.err
+}
+
+func (it *boltIterator) Close() error {
+	return it.tx.Rollback()
+}
+
+type batchOp struct {
+	del   bool
+	key   string
+	value string
+	ttl   int
+}
+
+// boltBatch stages writes/deletes and applies them inside a single bbolt
+// Update transaction on Commit, so the whole batch is atomic.
+type boltBatch struct {
+	storage *Storage
+	ops     []batchOp
+}
+
+func (b *boltBatch) Set(key, value string, ttl int) kv.Batch {
+	b.ops = append(b.ops, batchOp{key: key, value: value, ttl: ttl})
+	return b
+}
+
+// SetTTL is a no-op for boltdb: TTL isn't tracked for this driver (see
+// Storage.MExpire/TTL above), so there's nothing to stage.
+func (b *boltBatch) SetTTL(key string, ttl int) kv.Batch {
+	return b
+}
+
+func (b *boltBatch) Delete(key string) kv.Batch {
+	b.ops = append(b.ops, batchOp{del: true, key: key})
+	return b
+}
+
+func (b *boltBatch) Commit(ctx context.Context) error {
+	ops := b.ops
+	b.ops = nil
+
+	return b.storage.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(b.storage.bucket)
+		for _, op := range ops {
+			if op.del {
+				if err := bkt.Delete([]byte(op.key)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := bkt.Put([]byte(op.key), []byte(op.value)); err != nil {
+				return err
+			}
+		}
+
+		// as in Set: the filter learns the written keys before the tx
+		// commits, so Has/MGet never get a false negative for them.
+		b.storage.filterMu.Lock()
+		if b.storage.filter != nil {
+			for _, op := range ops {
+				if !op.del {
+					b.storage.filter.Add([]byte(op.key))
+				}
+			}
+		}
+		b.storage.filterMu.Unlock()
+
+		return nil
+	})
+}
+
+func (b *boltBatch) Discard() {
+	b.ops = nil
+}
@@ -145,7 +145,7 @@ func TestConcurrentReadWriteTransactions(t *testing.T) {
 		defer wg.Done()
 		for i := 0; i <= 1000; i++ {
 			m.Lock()
-			err = s.Delete(ctx, "key" + strconv.Itoa(i))
+			err = s.Delete(ctx, "key"+strconv.Itoa(i))
 			assert.NoError(t, err)
 			m.Unlock()
 		}
@@ -581,3 +581,165 @@ func TestStorage_SetExpire_TTL(t *testing.T) {
 //	assert.False(t, v["key"])
 //	assert.False(t, v["key2"])
 //}
+
+// TestBloomStaysDisabledWithoutConfig guards against Rebuild silently
+// turning the bloom filter on with hardcoded defaults once enough deletes
+// pile up, even though BloomExpectedItems == 0 (the default, documented to
+// mean "disabled") was never overridden.
+func TestBloomStaysDisabledWithoutConfig(t *testing.T) {
+	s := initStorage()
+	defer func() {
+		if err := s.Close(); err != nil {
+			panic(err)
+		}
+		cleanup(t, "rr.db")
+	}()
+
+	storage := s.(*Storage)
+	ctx := context.Background()
+
+	for i := 0; i < rebuildAfterDeletes+10; i++ {
+		key := "bloomkey" + strconv.Itoa(i)
+		assert.NoError(t, storage.Set(ctx, kv.Item{Key: key, Value: "v"}))
+		assert.NoError(t, storage.Delete(ctx, key))
+	}
+
+	assert.Nil(t, storage.filter)
+}
+
+// TestBloomSetVisibleBeforeReturn guards against Set committing the bolt
+// tx before adding the written keys to the bloom filter, which left a
+// window where a concurrent Has/MGet could get a false negative off the
+// bloom fast-path for a key that had just been written.
+func TestBloomSetVisibleBeforeReturn(t *testing.T) {
+	storage, err := NewBoltClient("rr_bloom.db", 0777, nil, "rr", time.Second)
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, storage.Close())
+		cleanup(t, "rr_bloom.db")
+	}()
+
+	s := storage.(*Storage)
+	_, err = s.Init(&Config{BloomExpectedItems: 100})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, storage.Set(ctx, kv.Item{Key: "key", Value: "hello"}))
+
+	assert.True(t, s.maybePresent("key"))
+
+	v, err := storage.Has(ctx, "key")
+	assert.NoError(t, err)
+	assert.True(t, v["key"])
+}
+
+// seedIteratorKeys writes a, b, c, d, e into storage for the Iterator tests
+// below.
+func seedIteratorKeys(t *testing.T, s kv.Storage) {
+	ctx := context.Background()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		assert.NoError(t, s.Set(ctx, kv.Item{Key: key, Value: key}))
+	}
+}
+
+func drainIterator(t *testing.T, it kv.Iterator) []string {
+	var keys []string
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	assert.NoError(t, it.Error())
+	assert.NoError(t, it.Close())
+	return keys
+}
+
+// TestIterator_ForwardRange guards the half-open [start, end) contract:
+// start is inclusive, end is exclusive.
+func TestIterator_ForwardRange(t *testing.T) {
+	s := initStorage()
+	defer func() {
+		assert.NoError(t, s.Close())
+		cleanup(t, "rr.db")
+	}()
+	seedIteratorKeys(t, s)
+
+	it, err := s.(kv.Iterable).Iterator(context.Background(), []byte("b"), []byte("d"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, drainIterator(t, it))
+}
+
+// TestIterator_NilStartEnd guards a nil start/end meaning "from the first
+// key" / "to the last key".
+func TestIterator_NilStartEnd(t *testing.T) {
+	s := initStorage()
+	defer func() {
+		assert.NoError(t, s.Close())
+		cleanup(t, "rr.db")
+	}()
+	seedIteratorKeys(t, s)
+
+	it, err := s.(kv.Iterable).Iterator(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, drainIterator(t, it))
+}
+
+// TestIterator_EndOnExistingKey guards the forward end check in
+// setPosition: an end that falls exactly on an existing key must still
+// exclude it.
+func TestIterator_EndOnExistingKey(t *testing.T) {
+	s := initStorage()
+	defer func() {
+		assert.NoError(t, s.Close())
+		cleanup(t, "rr.db")
+	}()
+	seedIteratorKeys(t, s)
+
+	it, err := s.(kv.Iterable).Iterator(context.Background(), []byte("a"), []byte("c"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, drainIterator(t, it))
+}
+
+// TestReverseIterator_Range guards descending order over [start, end), with
+// start inclusive and end exclusive just like the forward iterator.
+func TestReverseIterator_Range(t *testing.T) {
+	s := initStorage()
+	defer func() {
+		assert.NoError(t, s.Close())
+		cleanup(t, "rr.db")
+	}()
+	seedIteratorKeys(t, s)
+
+	it, err := s.(kv.Iterable).ReverseIterator(context.Background(), []byte("b"), []byte("d"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c", "b"}, drainIterator(t, it))
+}
+
+// TestReverseIterator_NilStartEnd guards a nil start/end walking the whole
+// keyspace in descending order.
+func TestReverseIterator_NilStartEnd(t *testing.T) {
+	s := initStorage()
+	defer func() {
+		assert.NoError(t, s.Close())
+		cleanup(t, "rr.db")
+	}()
+	seedIteratorKeys(t, s)
+
+	it, err := s.(kv.Iterable).ReverseIterator(context.Background(), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"e", "d", "c", "b", "a"}, drainIterator(t, it))
+}
+
+// TestReverseIterator_EndOnExistingKey guards seekFirst's reverse-mode Seek/
+// Prev boundary: an end landing exactly on an existing key must still
+// exclude that key, same as the forward case.
+func TestReverseIterator_EndOnExistingKey(t *testing.T) {
+	s := initStorage()
+	defer func() {
+		assert.NoError(t, s.Close())
+		cleanup(t, "rr.db")
+	}()
+	seedIteratorKeys(t, s)
+
+	it, err := s.(kv.Iterable).ReverseIterator(context.Background(), nil, []byte("c"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, drainIterator(t, it))
+}
@@ -0,0 +1,14 @@
+package fsdb
+
+import "time"
+
+// Config for the fsdb driver, used for RR integration.
+type Config struct {
+	// Dir is the directory keys are stored under. Created if missing.
+	Dir string
+	// Permissions for the directory and the key files created inside it.
+	Permissions int
+	// GCTick is the interval at which expired keys are swept. Defaults to
+	// one second when zero.
+	GCTick time.Duration
+}
@@ -0,0 +1,343 @@
+package fsdb
+
+import (
+	"context"
+	"encoding/hex"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spiral/kv"
+)
+
+// lockShards is the number of per-key mutex shards. Sized so that
+// concurrent writers to different keys rarely contend on the same shard.
+const lockShards = 32
+
+// Storage is a filesystem-backed K/V storage: every key is stored as its
+// own file (hex-encoded key as the filename, value as the file contents),
+// with an accompanying ".ttl" sidecar file holding the expiration unix
+// timestamp when the key has a TTL. This avoids boltdb's single-writer
+// lock and is friendly to backup/rsync workflows, at the cost of an open
+// file per key during access.
+type Storage struct {
+	dir  string
+	perm os.FileMode
+
+	// locks shards per-key locking so writes to unrelated keys don't
+	// serialize behind a single mutex.
+	locks [lockShards]sync.RWMutex
+
+	stop chan struct{}
+
+	// wait group is used to prevent Serve for early exit
+	// is used together with stop
+	// BUT NOT USED IN GENERAL API, ONLY FOR RoadRunner
+	wg *sync.WaitGroup
+
+	// config for RR integration
+	cfg *Config
+}
+
+// NewFSDBStorage creates (if needed) dir and returns a Storage rooted there.
+// A background sweeper purges expired keys every gcTick.
+func NewFSDBStorage(dir string, perm os.FileMode, gcTick time.Duration) (kv.Storage, error) {
+	if err := os.MkdirAll(dir, perm); err != nil {
+		return nil, err
+	}
+
+	if gcTick <= 0 {
+		gcTick = time.Second
+	}
+
+	s := &Storage{
+		dir:  dir,
+		perm: perm,
+		stop: make(chan struct{}),
+	}
+
+	go s.gcPhase(gcTick)
+
+	return s, nil
+}
+
+func (s *Storage) Init(config *Config) (bool, error) {
+	if config == nil {
+		return false, kv.ErrNoConfig
+	}
+	s.cfg = config
+	return true, nil
+}
+
+func (s *Storage) Has(ctx context.Context, keys ...string) (map[string]bool, error) {
+	if keys == nil {
+		return nil, kv.ErrNoKeys
+	}
+
+	m := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		keyTrimmed := strings.TrimSpace(key)
+		if keyTrimmed == "" {
+			return nil, kv.ErrEmptyKey
+		}
+
+		s.lockFor(key).RLock()
+		_, err := os.Stat(s.path(key))
+		expired := s.expired(key)
+		s.lockFor(key).RUnlock()
+
+		m[key] = err == nil && !expired
+	}
+
+	return m, nil
+}
+
+func (s *Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	keyTrimmed := strings.TrimSpace(key)
+	if keyTrimmed == "" {
+		return nil, kv.ErrEmptyKey
+	}
+
+	l := s.lockFor(key)
+	l.RLock()
+	defer l.RUnlock()
+
+	if s.expired(key) {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *Storage) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if keys == nil {
+		return nil, kv.ErrNoKeys
+	}
+
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			return nil, kv.ErrEmptyKey
+		}
+	}
+
+	m := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		data, err := s.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			m = append(m, data)
+		}
+	}
+
+	return m, nil
+}
+
+func (s *Storage) Set(ctx context.Context, items ...kv.Item) error {
+	if items == nil {
+		return kv.ErrNoKeys
+	}
+
+	for _, item := range items {
+		if item.Key == "" {
+			return kv.ErrEmptyKey
+		}
+
+		l := s.lockFor(item.Key)
+		l.Lock()
+		err := ioutil.WriteFile(s.path(item.Key), []byte(item.Value), s.perm)
+		if err == nil {
+			if item.TTL > 0 {
+				err = s.writeTTL(item.Key, time.Now().Add(time.Duration(item.TTL)*time.Second).Unix())
+			} else {
+				_ = os.Remove(s.ttlPath(item.Key))
+			}
+		}
+		l.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MExpire sets the TTL for multiply keys
+func (s *Storage) MExpire(ctx context.Context, timeout int, keys ...string) error {
+	if timeout == 0 || keys == nil {
+		return kv.ErrEmptyKey
+	}
+
+	expireAt := time.Now().Add(time.Duration(timeout) * time.Second).Unix()
+	for _, key := range keys {
+		l := s.lockFor(key)
+		l.Lock()
+		err := s.writeTTL(key, expireAt)
+		l.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) TTL(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	if keys == nil {
+		return nil, kv.ErrNoKeys
+	}
+
+	m := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		l := s.lockFor(key)
+		l.RLock()
+		expireAt, ok := s.readTTL(key)
+		l.RUnlock()
+		if ok {
+			m[key] = expireAt
+		}
+	}
+
+	return m, nil
+}
+
+func (s *Storage) Delete(ctx context.Context, keys ...string) error {
+	if keys == nil {
+		return kv.ErrNoKeys
+	}
+
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			return kv.ErrEmptyKey
+		}
+	}
+
+	for _, key := range keys {
+		l := s.lockFor(key)
+		l.Lock()
+		err := s.removeFilesLocked(key)
+		l.Unlock()
+
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeFilesLocked removes key's value file and its TTL sidecar, if any.
+// Caller must hold the key's write lock.
+func (s *Storage) removeFilesLocked(key string) error {
+	err := os.Remove(s.path(key))
+	_ = os.Remove(s.ttlPath(key))
+	return err
+}
+
+func (s *Storage) Close() error {
+	close(s.stop)
+	return nil
+}
+
+//================================== PRIVATE ======================================
+
+func (s *Storage) path(key string) string {
+	return filepath.Join(s.dir, hex.EncodeToString([]byte(key)))
+}
+
+func (s *Storage) ttlPath(key string) string {
+	return s.path(key) + ".ttl"
+}
+
+func (s *Storage) writeTTL(key string, expireAt int64) error {
+	return ioutil.WriteFile(s.ttlPath(key), []byte(strconv.FormatInt(expireAt, 10)), s.perm)
+}
+
+// readTTL returns the expiration unix timestamp for key, if any.
+func (s *Storage) readTTL(key string) (int64, bool) {
+	data, err := ioutil.ReadFile(s.ttlPath(key))
+	if err != nil {
+		return 0, false
+	}
+
+	expireAt, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return expireAt, true
+}
+
+// expired reports whether key has a TTL sidecar and it is in the past.
+// Caller must hold the key's lock.
+func (s *Storage) expired(key string) bool {
+	expireAt, ok := s.readTTL(key)
+	if !ok {
+		return false
+	}
+	return time.Now().Unix() > expireAt
+}
+
+// lockFor returns the mutex guarding key, sharded by an FNV hash of the key
+// so unrelated keys rarely serialize on the same lock.
+func (s *Storage) lockFor(key string) *sync.RWMutex {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return &s.locks[h.Sum32()%lockShards]
+}
+
+// gcPhase periodically sweeps expired keys from disk.
+func (s *Storage) gcPhase(tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			entries, err := ioutil.ReadDir(s.dir)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if !strings.HasSuffix(entry.Name(), ".ttl") {
+					continue
+				}
+				key, err := hex.DecodeString(strings.TrimSuffix(entry.Name(), ".ttl"))
+				if err != nil {
+					continue
+				}
+
+				// The expired check and the removal must happen under the
+				// same held lock: re-acquiring it later (or calling the
+				// public Delete, which takes its own lock separately)
+				// would leave a window where a concurrent Set/MExpire
+				// refreshing the key's TTL is invisible to this sweep and
+				// the now-stale "expired" verdict still wins.
+				keyStr := string(key)
+				l := s.lockFor(keyStr)
+				l.Lock()
+				if s.expired(keyStr) {
+					_ = s.removeFilesLocked(keyStr)
+				}
+				l.Unlock()
+			}
+		}
+	}
+}
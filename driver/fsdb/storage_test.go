@@ -0,0 +1,56 @@
+package fsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiral/kv"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGCPhase_RefreshedKeySurvivesSweep guards against gcPhase checking
+// expired and removing the key's files as two separate critical sections:
+// if a concurrent Set can land in the gap between the check and the
+// os.Remove calls, the sweeper can wipe out a key that was refreshed right
+// before its stale TTL was observed. A goroutine continuously flips the
+// key between expired and refreshed while gcPhase's ticker is actively
+// sweeping with a short tick, so the gap (if it existed) would be hit many
+// times over the run.
+func TestGCPhase_RefreshedKeySurvivesSweep(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewFSDBStorage(dir, 0644, time.Millisecond)
+	assert.NoError(t, err)
+	s := storage.(*Storage)
+	defer close(s.stop)
+
+	ctx := context.Background()
+	assert.NoError(t, s.Set(ctx, kv.Item{Key: "key", Value: "v1", TTL: 3600}))
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			// Make the key look expired, same as if its TTL had just
+			// elapsed, then immediately refresh it, racing gcPhase's
+			// next tick.
+			assert.NoError(t, s.writeTTL("key", time.Now().Add(-time.Hour).Unix()))
+			assert.NoError(t, s.Set(ctx, kv.Item{Key: "key", Value: "v2", TTL: 3600}))
+		}
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	close(stop)
+	<-done
+
+	v, err := s.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(v))
+}
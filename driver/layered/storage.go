@@ -0,0 +1,222 @@
+// Package layered composes a fast local L1 (typically memory.Storage) in
+// front of an authoritative L2 (e.g. redis.Storage), the caching-layer
+// pattern used by RoadRunner to avoid round-tripping to Redis for hot
+// keys while keeping Redis as the source of truth across workers.
+package layered
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/spiral/kv"
+)
+
+// Config controls how long an L2 value is cached in L1 after a miss.
+type Config struct {
+	// L1TTL is the TTL, in seconds, applied when populating L1 on an L2
+	// hit. 0 means the value is cached in L1 without expiring on its own
+	// (it's still cleared by write-through invalidation).
+	L1TTL int
+}
+
+// Stats reports L1 hit/miss counters for a Storage.
+type Stats struct {
+	L1Hits   int64
+	L1Misses int64
+}
+
+// Storage is a kv.Storage that checks L1 before falling back to L2,
+// populating L1 on miss and invalidating it on every write so L1 never
+// serves stale data for longer than it takes the write-through to land.
+type Storage struct {
+	l1  kv.Storage
+	l2  kv.Storage
+	cfg Config
+
+	hits   int64
+	misses int64
+}
+
+// New returns a layered Storage backed by l1 (fast, local) and l2
+// (authoritative, shared).
+func New(l1, l2 kv.Storage, cfg Config) *Storage {
+	return &Storage{l1: l1, l2: l2, cfg: cfg}
+}
+
+func (s *Storage) Has(ctx context.Context, keys ...string) (map[string]bool, error) {
+	have, err := s.l1.Has(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !have[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		atomic.AddInt64(&s.hits, int64(len(keys)))
+		return have, nil
+	}
+
+	l2Have, err := s.l2.Has(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+	for key, ok := range l2Have {
+		have[key] = ok
+	}
+
+	atomic.AddInt64(&s.hits, int64(len(keys)-len(missing)))
+	atomic.AddInt64(&s.misses, int64(len(missing)))
+
+	return have, nil
+}
+
+// Get checks L1 first; on miss it falls back to L2 and populates L1 with
+// cfg.L1TTL so the next Get for this key is served locally.
+func (s *Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	if data, err := s.l1.Get(ctx, key); err == nil && data != nil {
+		atomic.AddInt64(&s.hits, 1)
+		return data, nil
+	}
+
+	atomic.AddInt64(&s.misses, 1)
+	data, err := s.l2.Get(ctx, key)
+	if err != nil || data == nil {
+		return data, err
+	}
+
+	_ = s.l1.Set(ctx, kv.Item{Key: key, Value: string(data), TTL: s.cfg.L1TTL})
+	return data, nil
+}
+
+// MGet is Get, batched: keys found in L1 are returned as-is, the rest are
+// fetched from L2 in one call and used to populate L1.
+//
+// L1 is read key-by-key via Get rather than via a bulk l1.MGet()+l1.Has()
+// pair: memory.Storage's MGet returns values without their keys, so
+// zipping its results back onto keys needs a second Has() call to know
+// which keys hit, and that call sees its own independent snapshot of L1.
+// A key that's live for one call but expires before the other (or is
+// concurrently Set/Deleted) desyncs the zip, silently attributing the
+// wrong value to every key after it. Get resolves presence and value for
+// one key atomically, so there's nothing to desync.
+func (s *Storage) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	found := make(map[string]interface{}, len(keys))
+	missing := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		data, err := s.l1.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			missing = append(missing, key)
+			continue
+		}
+		// memory.Storage's own MGet returns values as string, not
+		// []byte; match that so a value's type doesn't depend on
+		// whether it was served from L1 or L2.
+		found[key] = string(data)
+	}
+
+	atomic.AddInt64(&s.hits, int64(len(keys)-len(missing)))
+
+	if len(missing) > 0 {
+		atomic.AddInt64(&s.misses, int64(len(missing)))
+
+		l2Res, err := s.l2.MGet(ctx, missing...)
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]kv.Item, 0, len(l2Res))
+		for i, key := range missing {
+			if i >= len(l2Res) {
+				break
+			}
+			found[key] = l2Res[i]
+			switch value := l2Res[i].(type) {
+			case []byte:
+				items = append(items, kv.Item{Key: key, Value: string(value), TTL: s.cfg.L1TTL})
+			case string:
+				items = append(items, kv.Item{Key: key, Value: value, TTL: s.cfg.L1TTL})
+			}
+		}
+		if len(items) > 0 {
+			_ = s.l1.Set(ctx, items...)
+		}
+	}
+
+	res := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if v, ok := found[key]; ok {
+			res = append(res, v)
+		}
+	}
+
+	return res, nil
+}
+
+// Set writes through to L2 then invalidates L1 for the written keys,
+// rather than updating L1 in place, so readers never see a value in L1
+// that L2 rejected.
+func (s *Storage) Set(ctx context.Context, items ...kv.Item) error {
+	if err := s.l2.Set(ctx, items...); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+
+	return s.l1.Delete(ctx, keys...)
+}
+
+func (s *Storage) MExpire(ctx context.Context, timeout int, keys ...string) error {
+	if err := s.l2.MExpire(ctx, timeout, keys...); err != nil {
+		return err
+	}
+	return s.l1.Delete(ctx, keys...)
+}
+
+// TTL is answered from L2, since L1's TTL is an internal caching detail
+// and may not match the authoritative expiration.
+func (s *Storage) TTL(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	return s.l2.TTL(ctx, keys...)
+}
+
+func (s *Storage) Delete(ctx context.Context, keys ...string) error {
+	if err := s.l2.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	return s.l1.Delete(ctx, keys...)
+}
+
+// InvalidateKeys drops keys from this node's L1 without touching L2. It's
+// the hook other nodes call (over RPC, see kv.RpcServer.Invalidate) so a
+// write on one RoadRunner worker doesn't leave stale data cached in
+// another worker's L1.
+func (s *Storage) InvalidateKeys(keys ...string) {
+	_ = s.l1.Delete(context.Background(), keys...)
+}
+
+// Stats returns the current L1 hit/miss counters.
+func (s *Storage) Stats() Stats {
+	return Stats{
+		L1Hits:   atomic.LoadInt64(&s.hits),
+		L1Misses: atomic.LoadInt64(&s.misses),
+	}
+}
+
+func (s *Storage) Close() error {
+	err1 := s.l1.Close()
+	err2 := s.l2.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
@@ -0,0 +1,93 @@
+package layered
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiral/kv"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStorage is a minimal in-memory kv.Storage stand-in, so MGet's L1/L2
+// wiring can be tested without a real memory/redis backend.
+type fakeStorage struct {
+	values map[string]string
+}
+
+func newFakeStorage(values map[string]string) *fakeStorage {
+	if values == nil {
+		values = map[string]string{}
+	}
+	return &fakeStorage{values: values}
+}
+
+func (f *fakeStorage) Has(_ context.Context, keys ...string) (map[string]bool, error) {
+	m := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		_, ok := f.values[key]
+		m[key] = ok
+	}
+	return m, nil
+}
+
+func (f *fakeStorage) Get(_ context.Context, key string) ([]byte, error) {
+	if v, ok := f.values[key]; ok {
+		return []byte(v), nil
+	}
+	return nil, nil
+}
+
+func (f *fakeStorage) MGet(_ context.Context, keys ...string) ([]interface{}, error) {
+	res := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if v, ok := f.values[key]; ok {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeStorage) Set(_ context.Context, items ...kv.Item) error {
+	for _, item := range items {
+		f.values[item.Key] = item.Value
+	}
+	return nil
+}
+
+func (f *fakeStorage) MExpire(_ context.Context, _ int, _ ...string) error {
+	return nil
+}
+
+func (f *fakeStorage) TTL(_ context.Context, _ ...string) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (f *fakeStorage) Delete(_ context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(f.values, key)
+	}
+	return nil
+}
+
+func (f *fakeStorage) Close() error {
+	return nil
+}
+
+// TestStorage_MGet_PartialL1Hit guards against the desync bug where MGet
+// paired an independent l1.MGet() with an l1.Has() call and zipped their
+// results by a shared index: any mismatch between the two (a key
+// live/gone between calls, or simply a key landing at a different
+// position than expected) attributed the wrong value to every key after
+// it. Keyed, per-key L1 reads can't desync like that.
+func TestStorage_MGet_PartialL1Hit(t *testing.T) {
+	l1 := newFakeStorage(map[string]string{"b": "l1-b"})
+	l2 := newFakeStorage(map[string]string{"a": "l2-a", "c": "l2-c"})
+
+	s := New(l1, l2, Config{})
+	res, err := s.MGet(context.Background(), "a", "b", "c")
+	assert.NoError(t, err)
+	assert.Len(t, res, 3)
+	assert.Equal(t, "l2-a", res[0])
+	assert.Equal(t, "l1-b", res[1])
+	assert.Equal(t, "l2-c", res[2])
+}
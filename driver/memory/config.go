@@ -0,0 +1,37 @@
+package memory
+
+import "github.com/sirupsen/logrus"
+
+// Config for the in-memory driver, used for RR integration.
+type Config struct {
+	// Enabled toggles whether Serve actually starts the storage; kept so
+	// RR can list the driver in config without always running it.
+	Enabled bool
+
+	// MaxEntries caps the number of keys retained, split evenly across
+	// shards (MaxEntries/Shards each, at least 1). Once a shard holds more
+	// than its share, that shard's least recently set key is evicted, so
+	// eviction can start before MaxEntries keys are held in total, and up
+	// to Shards keys are kept when MaxEntries < Shards. Zero means
+	// unbounded.
+	MaxEntries int
+	// MaxBytes caps the size (keys + values, in bytes) retained, split
+	// evenly across shards the same way as MaxEntries; a shard over its
+	// share evicts its own least recently set key. Zero means unbounded.
+	MaxBytes int64
+	// Shards is how many lock-guarded shards the keyspace is split
+	// across to reduce contention. Defaults to 16 when zero.
+	Shards int
+
+	log *logrus.Logger
+}
+
+func (c Config) withDefaults() Config {
+	if c.Shards <= 0 {
+		c.Shards = 16
+	}
+	if c.log == nil {
+		c.log = logrus.StandardLogger()
+	}
+	return c
+}
@@ -1,17 +1,276 @@
 package memory
 
 import (
+	"container/heap"
+	"container/list"
 	"context"
 	"errors"
+	"github.com/cespare/xxhash/v2"
 	"github.com/spiral/kv"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// item is a single stored value plus the bookkeeping its shard needs for
+// TTL expiry and LRU eviction.
+type item struct {
+	key   string
+	value string
+	// expiresAt is the TTL deadline in unix seconds; zero means the item
+	// never expires.
+	expiresAt int64
+
+	// heapIdx is this item's position on its shard's expiry heap, kept in
+	// sync by expiryHeap.Swap. -1 means "not on the heap" (no TTL), so
+	// Delete/overwrite can heap.Remove it in O(log N) instead of scanning
+	// for it.
+	heapIdx int
+	// lruElem is this item's node in its shard's LRU list.
+	lruElem *list.Element
+}
+
+// expiryHeap is a container/heap of *item ordered by expiresAt, so the GC
+// goroutine can sleep until the next real deadline instead of polling.
+type expiryHeap []*item
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].expiresAt < h[j].expiresAt }
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIdx = i
+	h[j].heapIdx = j
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	it := x.(*item)
+	it.heapIdx = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	it.heapIdx = -1
+	*h = old[:n-1]
+	return it
+}
+
+// shard owns a slice of the keyspace: a lock-guarded map, an expiry heap
+// for the TTL'd subset of its keys, and an LRU list used to pick an
+// eviction candidate once the shard's share of MaxEntries/MaxBytes is
+// exceeded. LRU order is only touched on Set, not on reads, so Has/Get/MGet
+// only ever need the RLock.
+type shard struct {
+	mu       sync.RWMutex
+	items    map[string]*item
+	expiries expiryHeap
+	lru      *list.List // front = most recently set
+
+	bytes int64 // approximate size of keys+values held by this shard
+}
+
+func newShard() *shard {
+	return &shard{
+		items: make(map[string]*item),
+		lru:   list.New(),
+	}
+}
+
+// getLive returns the item for key if present and not past its expiry,
+// without waiting for the GC goroutine to have swept it yet.
+func (sh *shard) getLive(key string, now int64) (*item, bool) {
+	sh.mu.RLock()
+	it, ok := sh.items[key]
+	expired := ok && it.heapIdx >= 0 && it.expiresAt <= now
+	sh.mu.RUnlock()
+	if !ok || expired {
+		return nil, false
+	}
+	return it, true
+}
+
+// setExpiry updates the item's expiresAt and keeps the shard's heap membership in
+// sync: push it onto the heap, fix its position, or remove it, depending
+// on whether it had/wants a TTL. Caller must hold sh.mu.
+func (sh *shard) setExpiry(it *item, expiresAt int64) {
+	hadTTL := it.heapIdx >= 0
+	wantsTTL := expiresAt > 0
+	it.expiresAt = expiresAt
+
+	switch {
+	case hadTTL && wantsTTL:
+		heap.Fix(&sh.expiries, it.heapIdx)
+	case hadTTL && !wantsTTL:
+		heap.Remove(&sh.expiries, it.heapIdx)
+	case !hadTTL && wantsTTL:
+		heap.Push(&sh.expiries, it)
+	}
+}
+
+// set creates or overwrites key, then enforces maxEntries/maxBytes by
+// evicting from the back of the LRU list (the least recently set item)
+// until the shard is back under budget. maxEntries/maxBytes are already
+// this shard's share of the configured total; 0 means unbounded.
+func (sh *shard) set(key, value string, expiresAt int64, maxEntries int, maxBytes int64, evictions *uint64) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.setLocked(key, value, expiresAt, maxEntries, maxBytes, evictions)
+}
+
+// setLocked is set without acquiring sh.mu, for callers (Batch.Commit) that
+// already hold it across several ops. Caller must hold sh.mu.
+func (sh *shard) setLocked(key, value string, expiresAt int64, maxEntries int, maxBytes int64, evictions *uint64) {
+	if it, ok := sh.items[key]; ok {
+		sh.bytes += int64(len(value)) - int64(len(it.value))
+		it.value = value
+		sh.setExpiry(it, expiresAt)
+		sh.lru.MoveToFront(it.lruElem)
+	} else {
+		it := &item{key: key, value: value, heapIdx: -1}
+		it.lruElem = sh.lru.PushFront(it)
+		sh.items[key] = it
+		sh.bytes += int64(len(key)) + int64(len(value))
+		sh.setExpiry(it, expiresAt)
+	}
+
+	for (maxEntries > 0 && len(sh.items) > maxEntries) || (maxBytes > 0 && sh.bytes > maxBytes) {
+		back := sh.lru.Back()
+		if back == nil {
+			break
+		}
+		sh.removeLocked(back.Value.(*item))
+		atomic.AddUint64(evictions, 1)
+	}
+}
+
+// setTTL updates the expiry of an existing key, leaving it untouched if
+// absent. Caller must not hold sh.mu.
+func (sh *shard) setTTL(key string, expiresAt int64) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.setTTLLocked(key, expiresAt)
+}
+
+// setTTLLocked is setTTL without acquiring sh.mu. Caller must hold sh.mu.
+func (sh *shard) setTTLLocked(key string, expiresAt int64) {
+	if it, ok := sh.items[key]; ok {
+		sh.setExpiry(it, expiresAt)
+	}
+}
+
+// delete removes key if present. Caller must not hold sh.mu.
+func (sh *shard) delete(key string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.deleteLocked(key)
+}
+
+// deleteLocked is delete without acquiring sh.mu. Caller must hold sh.mu.
+func (sh *shard) deleteLocked(key string) {
+	if it, ok := sh.items[key]; ok {
+		sh.removeLocked(it)
+	}
+}
+
+// removeLocked drops it from the map, LRU list and expiry heap. Caller
+// must hold sh.mu.
+func (sh *shard) removeLocked(it *item) {
+	delete(sh.items, it.key)
+	sh.lru.Remove(it.lruElem)
+	if it.heapIdx >= 0 {
+		heap.Remove(&sh.expiries, it.heapIdx)
+	}
+	sh.bytes -= int64(len(it.key)) + int64(len(it.value))
+}
+
+// sweepExpired pops every item whose expiry has passed and reports how
+// many were removed via onExpire. Caller must not hold sh.mu.
+func (sh *shard) sweepExpired(onExpire func(n int)) {
+	now := time.Now().Unix()
+
+	sh.mu.Lock()
+	n := 0
+	for len(sh.expiries) > 0 && sh.expiries[0].expiresAt <= now {
+		it := heap.Pop(&sh.expiries).(*item)
+		delete(sh.items, it.key)
+		sh.lru.Remove(it.lruElem)
+		sh.bytes -= int64(len(it.key)) + int64(len(it.value))
+		n++
+	}
+	sh.mu.Unlock()
+
+	if n > 0 {
+		onExpire(n)
+	}
+}
+
+// nextDeadline returns the shard's soonest expiry, or ok=false if nothing
+// on this shard has a TTL.
+func (sh *shard) nextDeadline() (int64, bool) {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	if len(sh.expiries) == 0 {
+		return 0, false
+	}
+	return sh.expiries[0].expiresAt, true
+}
+
+// gc sleeps until this shard's next expiry as known when the sleep starts
+// (or an hour, absent any TTL'd key) and sweeps whatever has expired by
+// then, instead of polling on a fixed tick. A TTL set during the sleep with
+// an earlier deadline is swept only when that sleep ends; getLive already
+// hides such an item from reads.
+func (sh *shard) gc(stop <-chan struct{}, onExpire func(n int)) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		wait := time.Hour
+		if deadline, ok := sh.nextDeadline(); ok {
+			if until := time.Until(time.Unix(deadline, 0)); until < wait {
+				wait = until
+			}
+		}
+		if wait < 0 {
+			wait = 0
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+			sh.sweepExpired(onExpire)
+		}
+	}
+}
+
+// Stats reports cumulative counters for the storage's lifetime.
+type Stats struct {
+	// Evictions counts keys removed by the MaxEntries/MaxBytes LRU cap.
+	Evictions uint64
+	// Expirations counts keys removed because their TTL elapsed.
+	Expirations uint64
+}
+
 type Storage struct {
-	heap *sync.Map //map[string]item
-	stop chan struct{}
+	shards []*shard
+	stop   chan struct{}
+
+	// batchMu serializes Batch.Commit so concurrent batches don't
+	// interleave, even though individual Set/Delete calls
+	// go straight to their shard without it. A pointer, like shards/stop,
+	// since Storage is passed around by value.
+	batchMu *sync.Mutex
+
+	evictions   *uint64
+	expirations *uint64
 
 	// config for RR integration
 	cfg *Config
@@ -22,10 +281,23 @@ type Storage struct {
 	wg *sync.WaitGroup
 }
 
+func newShards(n int) []*shard {
+	shards := make([]*shard, n)
+	for i := range shards {
+		shards[i] = newShard()
+	}
+	return shards
+}
+
 func NewInMemoryStorage() kv.Storage {
+	cfg := (&Config{}).withDefaults()
 	ttls := &Storage{
-		heap: &sync.Map{},
-		stop: make(chan struct{}),
+		shards:      newShards(cfg.Shards),
+		stop:        make(chan struct{}),
+		batchMu:     &sync.Mutex{},
+		evictions:   new(uint64),
+		expirations: new(uint64),
+		cfg:         &cfg,
 	}
 
 	go ttls.gcPhase()
@@ -37,7 +309,8 @@ func (s *Storage) Init(config *Config) (bool, error) {
 	if config == nil {
 		return false, kv.ErrNoConfig
 	}
-	s.cfg = config
+	cfg := config.withDefaults()
+	s.cfg = &cfg
 	return true, nil
 }
 
@@ -51,8 +324,9 @@ func (s Storage) Serve() error {
 	wg.Add(1)
 
 	// init in-memory
-	s.heap = &sync.Map{}
+	s.shards = newShards(s.cfg.Shards)
 	s.stop = make(chan struct{})
+	s.batchMu = &sync.Mutex{}
 
 	// start in-memory gc for kv
 	go s.gcPhase()
@@ -69,23 +343,58 @@ func (s Storage) Stop() {
 	}
 }
 
+func (s Storage) shardFor(key string) *shard {
+	return s.shards[s.shardIndexFor(key)]
+}
+
+func (s Storage) shardIndexFor(key string) int {
+	return int(xxhash.Sum64String(key) % uint64(len(s.shards)))
+}
+
+// shardLimits returns this storage's MaxEntries/MaxBytes split evenly
+// across its shards, so each shard can enforce its share independently
+// without a global lock. 0 stays 0 (unbounded).
+func (s Storage) shardLimits() (maxEntries int, maxBytes int64) {
+	if s.cfg == nil {
+		return 0, 0
+	}
+	if s.cfg.MaxEntries > 0 {
+		maxEntries = s.cfg.MaxEntries / len(s.shards)
+		if maxEntries == 0 {
+			maxEntries = 1
+		}
+	}
+	if s.cfg.MaxBytes > 0 {
+		maxBytes = s.cfg.MaxBytes / int64(len(s.shards))
+		if maxBytes == 0 {
+			maxBytes = 1
+		}
+	}
+	return maxEntries, maxBytes
+}
+
+// Stats returns a snapshot of the storage's eviction/expiration counters.
+func (s Storage) Stats() Stats {
+	return Stats{
+		Evictions:   atomic.LoadUint64(s.evictions),
+		Expirations: atomic.LoadUint64(s.expirations),
+	}
+}
+
 func (s Storage) Has(ctx context.Context, keys ...string) (map[string]bool, error) {
 	if keys == nil {
 		return nil, kv.ErrNoKeys
 	}
-	m := make(map[string]bool)
+	now := time.Now().Unix()
+	m := make(map[string]bool, len(keys))
 	for _, key := range keys {
-
 		keyTrimmed := strings.TrimSpace(key)
 		if keyTrimmed == "" {
 			return nil, kv.ErrEmptyKey
 		}
 
-		if _, ok := s.heap.Load(key); ok {
-			m[key] = true
-		} else {
-			m[key] = false
-		}
+		_, ok := s.shardFor(key).getLive(key, now)
+		m[key] = ok
 	}
 
 	return m, nil
@@ -98,10 +407,8 @@ func (s Storage) Get(ctx context.Context, key string) ([]byte, error) {
 		return nil, kv.ErrEmptyKey
 	}
 
-	if data, exist := s.heap.Load(key); exist {
-		// here might be a panic
-		// but data only could be a string, see Set function
-		return []byte(data.(kv.Item).Value), nil
+	if it, ok := s.shardFor(key).getLive(key, time.Now().Unix()); ok {
+		return []byte(it.value), nil
 	}
 	return nil, nil
 }
@@ -119,11 +426,12 @@ func (s Storage) MGet(ctx context.Context, keys ...string) ([]interface{}, error
 		}
 	}
 
+	now := time.Now().Unix()
 	m := make([]interface{}, 0, len(keys))
 
 	for _, key := range keys {
-		if value, ok := s.heap.Load(key); ok {
-			m = append(m, value)
+		if it, ok := s.shardFor(key).getLive(key, now); ok {
+			m = append(m, it.value)
 		}
 	}
 
@@ -134,22 +442,14 @@ func (s Storage) Set(ctx context.Context, items ...kv.Item) error {
 	if items == nil {
 		return kv.ErrNoKeys
 	}
+
+	maxEntries, maxBytes := s.shardLimits()
 	for _, kvItem := range items {
+		var expiresAt int64
 		if kvItem.TTL > 0 {
-			// heavy operation, but simple
-			s.heap.Store(kvItem.Key, kv.Item{
-				Key:   kvItem.Key,
-				Value: kvItem.Value,
-				TTL:   int(time.Unix(time.Now().Add(time.Second*time.Duration(kvItem.TTL)).Unix(), 0).Unix()),
-			})
-			continue
-		}
-		// heavy operation, but simple
-		s.heap.Store(kvItem.Key, kv.Item{
-			Key:   kvItem.Key,
-			Value: kvItem.Value,
-			TTL:   0,
-		})
+			expiresAt = time.Now().Add(time.Second * time.Duration(kvItem.TTL)).Unix()
+		}
+		s.shardFor(kvItem.Key).set(kvItem.Key, kvItem.Value, expiresAt, maxEntries, maxBytes, s.evictions)
 	}
 	return nil
 }
@@ -161,16 +461,9 @@ func (s Storage) MExpire(ctx context.Context, timeout int, keys ...string) error
 		return errors.New("should set timeout and at least one key")
 	}
 
-	ut := time.Unix(time.Now().Add(time.Second*time.Duration(timeout)).Unix(), 0).Unix()
+	expiresAt := time.Now().Add(time.Second * time.Duration(timeout)).Unix()
 	for _, key := range keys {
-		// if key exist, overwrite it value
-		if item, ok := s.heap.Load(key); ok {
-			kvItem := item.(kv.Item)
-			kvItem.TTL = int(ut)
-
-			s.heap.Store(key, kvItem)
-		}
-
+		s.shardFor(key).setTTL(key, expiresAt)
 	}
 	return nil
 }
@@ -188,11 +481,12 @@ func (s Storage) TTL(ctx context.Context, keys ...string) (map[string]interface{
 		}
 	}
 
+	now := time.Now().Unix()
 	m := make(map[string]interface{}, len(keys))
 
 	for _, key := range keys {
-		if item, ok := s.heap.Load(key); ok {
-			m[key] = item.(kv.Item).TTL
+		if it, ok := s.shardFor(key).getLive(key, now); ok {
+			m[key] = int(it.expiresAt)
 		}
 	}
 	return m, nil
@@ -212,38 +506,153 @@ func (s Storage) Delete(ctx context.Context, keys ...string) error {
 	}
 
 	for _, key := range keys {
-		s.heap.Delete(key)
+		s.shardFor(key).delete(key)
 	}
 	return nil
 }
 
+// Batch returns a Batch that applies every staged mutation on Commit while
+// holding the write lock of every shard the batch touches, so a concurrent
+// Has/Get/MGet/Set/Delete/Batch against any of those keys blocks until the
+// whole batch has landed. A multi-key Has or MGet reads one shard at a
+// time, so it can see some of a batch's keys from before the commit and
+// others from after it.
+func (s Storage) Batch() kv.Batch {
+	return &memoryBatch{storage: s}
+}
+
+type memoryOpKind int
+
+const (
+	memOpSet memoryOpKind = iota
+	memOpSetTTL
+	memOpDelete
+)
+
+type memoryOp struct {
+	kind  memoryOpKind
+	key   string
+	value string
+	ttl   int
+}
+
+// memoryBatch stages writes/deletes and applies them on Commit while
+// holding every touched shard's lock for the duration, so a read of any
+// one touched key sees it either before or after the whole batch.
+type memoryBatch struct {
+	storage Storage
+	ops     []memoryOp
+}
+
+func (b *memoryBatch) Set(key, value string, ttl int) kv.Batch {
+	b.ops = append(b.ops, memoryOp{kind: memOpSet, key: key, value: value, ttl: ttl})
+	return b
+}
+
+func (b *memoryBatch) SetTTL(key string, ttl int) kv.Batch {
+	b.ops = append(b.ops, memoryOp{kind: memOpSetTTL, key: key, ttl: ttl})
+	return b
+}
+
+func (b *memoryBatch) Delete(key string) kv.Batch {
+	b.ops = append(b.ops, memoryOp{kind: memOpDelete, key: key})
+	return b
+}
+
+func (b *memoryBatch) Commit(ctx context.Context) error {
+	ops := b.ops
+	b.ops = nil
+
+	// batchMu serializes concurrent Commits against each other; the
+	// per-shard locks below (held for the whole commit) are what keeps
+	// plain Has/Get/MGet/Set/Delete calls on a touched key from running
+	// in the middle of the batch.
+	b.storage.batchMu.Lock()
+	defer b.storage.batchMu.Unlock()
+
+	touched := b.lockTouchedShards(ops)
+	defer func() {
+		for _, sh := range touched {
+			sh.mu.Unlock()
+		}
+	}()
+
+	maxEntries, maxBytes := b.storage.shardLimits()
+	for _, op := range ops {
+		sh := b.storage.shardFor(op.key)
+		switch op.kind {
+		case memOpDelete:
+			sh.deleteLocked(op.key)
+		case memOpSetTTL:
+			expiresAt := time.Now().Add(time.Second * time.Duration(op.ttl)).Unix()
+			sh.setTTLLocked(op.key, expiresAt)
+		default:
+			var expiresAt int64
+			if op.ttl > 0 {
+				expiresAt = time.Now().Add(time.Second * time.Duration(op.ttl)).Unix()
+			}
+			sh.setLocked(op.key, op.value, expiresAt, maxEntries, maxBytes, b.storage.evictions)
+		}
+	}
+
+	return nil
+}
+
+// lockTouchedShards locks, in ascending shard-index order (so two
+// concurrent Commits touching overlapping shard sets can't deadlock), the
+// write lock of every distinct shard ops touches, and returns them in the
+// order they were locked so the caller can unlock in the same order.
+func (b *memoryBatch) lockTouchedShards(ops []memoryOp) []*shard {
+	seen := make(map[int]bool, len(ops))
+	indexes := make([]int, 0, len(ops))
+	for _, op := range ops {
+		idx := b.storage.shardIndexFor(op.key)
+		if !seen[idx] {
+			seen[idx] = true
+			indexes = append(indexes, idx)
+		}
+	}
+	sort.Ints(indexes)
+
+	shards := make([]*shard, len(indexes))
+	for i, idx := range indexes {
+		shards[i] = b.storage.shards[idx]
+		shards[i].mu.Lock()
+	}
+	return shards
+}
+
+func (b *memoryBatch) Discard() {
+	b.ops = nil
+}
+
 // Close clears the in-memory storage
 func (s Storage) Close() error {
-	s.heap = &sync.Map{}
-	s.stop <- struct{}{}
+	// replaced in place: s is a copy, but the shards slice is shared
+	for i := range s.shards {
+		s.shards[i] = newShard()
+	}
+	close(s.stop)
 	return nil
 }
 
 //================================== PRIVATE ======================================
 
-func (s *Storage) gcPhase() {
-	// TODO check
-	ticker := time.NewTicker(time.Second)
-	for {
-		select {
-		case <-s.stop:
-			ticker.Stop()
-			return
-		case now := <-ticker.C:
-			// check every second
-			s.heap.Range(func(key, value interface{}) bool {
-				v := value.(kv.Item)
-				if now.Unix() > time.Unix(int64(v.TTL), 0).Unix() {
-					s.heap.Delete(key)
-				}
-				return true
-			})
-		}
+// gcPhase runs one gc goroutine per shard, each sleeping until that
+// shard's next expiry instead of polling on a fixed tick.
+func (s Storage) gcPhase() {
+	onExpire := func(n int) {
+		atomic.AddUint64(s.expirations, uint64(n))
 	}
 
-}
\ No newline at end of file
+	var wg sync.WaitGroup
+	wg.Add(len(s.shards))
+	for _, sh := range s.shards {
+		sh := sh
+		go func() {
+			defer wg.Done()
+			sh.gc(s.stop, onExpire)
+		}()
+	}
+	wg.Wait()
+}
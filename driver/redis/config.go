@@ -0,0 +1,167 @@
+package redis
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// Mode selects which Redis topology Serve connects to.
+type Mode string
+
+const (
+	// ModeSingle talks to a single Redis instance. The default when Mode
+	// is left empty.
+	ModeSingle Mode = "single"
+	// ModeSentinel talks to a Redis Sentinel-managed failover set. Addr
+	// must list the sentinel addresses, and Master the monitored group
+	// name.
+	ModeSentinel Mode = "sentinel"
+	// ModeCluster talks to a Redis Cluster. Addr must list cluster seed
+	// node addresses; MGet/Delete are split per hash slot so a multi-key
+	// call never hits a real cluster's CROSSSLOT error, and MOVED/ASK
+	// redirects are followed automatically by the underlying client.
+	ModeCluster Mode = "cluster"
+)
+
+// Config for the redis driver, used for RR integration.
+type Config struct {
+	// Mode selects the topology Serve connects to. Defaults to
+	// ModeSingle when empty.
+	Mode Mode
+
+	// Addr lists node addresses: a single address for ModeSingle, the
+	// sentinel addresses for ModeSentinel, or the cluster seed nodes for
+	// ModeCluster.
+	Addr []string
+	// Db selects the logical database. Not supported in ModeCluster.
+	Db int
+	// Password authenticates the connection (requirepass/AUTH).
+	Password string
+	// Master is the sentinel-monitored group name. Required in
+	// ModeSentinel, ignored otherwise.
+	Master string
+
+	// PoolSize caps the number of connections per node. Defaults to
+	// go-redis's own default (10 per CPU) when zero.
+	PoolSize int
+	// MinIdleConns keeps at least this many idle connections open per
+	// node, so a traffic burst doesn't pay dial latency.
+	MinIdleConns int
+	// DialTimeout bounds how long connecting to a node may take.
+	DialTimeout time.Duration
+	// ReadTimeout bounds how long a single read may take.
+	ReadTimeout time.Duration
+	// WriteTimeout bounds how long a single write may take.
+	WriteTimeout time.Duration
+	// MaxRetries is how many times a command is retried on a retryable
+	// error before giving up. It does not bound MOVED/ASK redirects in
+	// ModeCluster; the cluster client's own redirect limit applies there.
+	MaxRetries int
+
+	// TLSConfig enables TLS on the connection when non-nil.
+	TLSConfig *tls.Config
+
+	// RouteByLatency routes cluster reads to the replica with the lowest
+	// latency. ModeCluster only.
+	RouteByLatency bool
+	// RouteRandomly routes cluster reads to a random replica instead of
+	// always the master. ModeCluster only.
+	RouteRandomly bool
+
+	log *logrus.Logger
+}
+
+func (c Config) withDefaults() Config {
+	if c.Mode == "" {
+		c.Mode = ModeSingle
+	}
+	if c.log == nil {
+		c.log = logrus.StandardLogger()
+	}
+	return c
+}
+
+// Validate checks the config is internally consistent for its Mode.
+func (c Config) Validate() error {
+	if len(c.Addr) == 0 {
+		return errors.New("kv/redis: Addr must list at least one address")
+	}
+
+	switch c.Mode {
+	case "", ModeSingle:
+	case ModeSentinel:
+		if c.Master == "" {
+			return errors.New("kv/redis: Master is required in sentinel mode")
+		}
+	case ModeCluster:
+		if c.Db != 0 {
+			return errors.New("kv/redis: Db is not supported in cluster mode")
+		}
+	default:
+		return fmt.Errorf("kv/redis: unknown mode %q", c.Mode)
+	}
+
+	return nil
+}
+
+// newClient builds the go-redis client matching c.Mode.
+func (c Config) newClient() redis.UniversalClient {
+	switch c.Mode {
+	case ModeCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    c.Addr,
+			Password: c.Password,
+
+			PoolSize:     c.PoolSize,
+			MinIdleConns: c.MinIdleConns,
+			DialTimeout:  c.DialTimeout,
+			ReadTimeout:  c.ReadTimeout,
+			WriteTimeout: c.WriteTimeout,
+			MaxRetries:   c.MaxRetries,
+
+			TLSConfig: c.TLSConfig,
+
+			RouteByLatency: c.RouteByLatency,
+			RouteRandomly:  c.RouteRandomly,
+		})
+	case ModeSentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			SentinelAddrs: c.Addr,
+			MasterName:    c.Master,
+			DB:            c.Db,
+			Password:      c.Password,
+
+			PoolSize:     c.PoolSize,
+			MinIdleConns: c.MinIdleConns,
+			DialTimeout:  c.DialTimeout,
+			ReadTimeout:  c.ReadTimeout,
+			WriteTimeout: c.WriteTimeout,
+			MaxRetries:   c.MaxRetries,
+
+			TLSConfig: c.TLSConfig,
+		})
+	default:
+		var addr string
+		if len(c.Addr) > 0 {
+			addr = c.Addr[0]
+		}
+		return redis.NewClient(&redis.Options{
+			Addr:     addr,
+			DB:       c.Db,
+			Password: c.Password,
+
+			PoolSize:     c.PoolSize,
+			MinIdleConns: c.MinIdleConns,
+			DialTimeout:  c.DialTimeout,
+			ReadTimeout:  c.ReadTimeout,
+			WriteTimeout: c.WriteTimeout,
+			MaxRetries:   c.MaxRetries,
+
+			TLSConfig: c.TLSConfig,
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package redis
+
+import "strings"
+
+// clusterSlots is the number of hash slots a Redis Cluster key space is
+// split into. https://redis.io/docs/reference/cluster-spec/#key-distribution-model
+const clusterSlots = 16384
+
+// crc16Table is the CRC-16/XMODEM lookup table (poly 0x1021, init 0),
+// which is the specific CRC variant Redis Cluster hashes keys with.
+var crc16Table = func() [256]uint16 {
+	var table [256]uint16
+	const poly = 0x1021
+	for i := range table {
+		crc := uint16(i) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = (crc << 1) ^ poly
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func crc16(data string) uint16 {
+	var crc uint16
+	for i := 0; i < len(data); i++ {
+		crc = (crc << 8) ^ crc16Table[byte(crc>>8)^data[i]]
+	}
+	return crc
+}
+
+// keySlot returns the Redis Cluster hash slot for key, extracting the
+// {hashtag} portion first when present so tagged keys land on the same
+// slot, same as every other Redis Cluster client.
+func keySlot(key string) uint16 {
+	hashed := key
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			hashed = key[start+1 : start+1+end]
+		}
+	}
+	return crc16(hashed) % clusterSlots
+}
+
+// groupBySlot partitions keys by their Redis Cluster hash slot, so a
+// multi-key command can be issued once per slot instead of hitting a
+// CROSSSLOT error on a real cluster.
+func groupBySlot(keys []string) [][]string {
+	bySlot := make(map[uint16][]string, len(keys))
+	for _, key := range keys {
+		slot := keySlot(key)
+		bySlot[slot] = append(bySlot[slot], key)
+	}
+
+	groups := make([][]string, 0, len(bySlot))
+	for _, group := range bySlot {
+		groups = append(groups, group)
+	}
+	return groups
+}
@@ -3,14 +3,57 @@ package redis
 import (
 	"context"
 	"errors"
-	"github.com/go-redis/redis/v7"
-	"github.com/sirupsen/logrus"
+	"fmt"
+	"github.com/go-redis/redis/v8"
 	"github.com/spiral/kv"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
+// PipelineError reports the per-key errors from a pipelined Redis call.
+// A pipeline round-trip isn't atomic, so part of the batch can land while
+// another part fails; Errors maps only the keys whose command failed.
+type PipelineError struct {
+	Errors map[string]error
+}
+
+func (e *PipelineError) Error() string {
+	return fmt.Sprintf("kv/redis: %d pipelined command(s) failed", len(e.Errors))
+}
+
+// pipelineErr builds a PipelineError from the Cmder each key's command ran
+// as, after a pipeline returned cause. If the round-trip failed before any
+// command was attempted (e.g. a dropped connection), every cmd carries the
+// same error, so every key is reported against cause instead.
+func pipelineErr(keys []string, cmds []redis.Cmder, cause error) error {
+	errs := make(map[string]error, len(keys))
+	for i, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			errs[keys[i]] = err
+		}
+	}
+	if len(errs) == 0 {
+		for _, key := range keys {
+			errs[key] = cause
+		}
+	}
+	return &PipelineError{Errors: errs}
+}
+
+// isCluster reports whether MGet/Delete must group keys by hash slot: cfg
+// says so, or s.client is a *redis.ClusterClient regardless of cfg (e.g.
+// built directly via NewRedisClient with cluster UniversalOptions, which
+// bypasses cfg entirely).
+func (s Storage) isCluster() bool {
+	if s.cfg != nil && s.cfg.Mode == ModeCluster {
+		return true
+	}
+	_, ok := s.client.(*redis.ClusterClient)
+	return ok
+}
+
 // Redis K/V storage.
 type Storage struct {
 	// config for RR integration
@@ -26,8 +69,9 @@ type Storage struct {
 
 func NewRedisClient(options *redis.UniversalOptions) kv.Storage {
 	universalClient := redis.NewUniversalClient(options)
+	cfg := (&Config{}).withDefaults()
 	s := &Storage{
-		cfg:    &Config{log: logrus.StandardLogger()},
+		cfg:    &cfg,
 		client: universalClient,
 	}
 	return s
@@ -37,25 +81,26 @@ func (s *Storage) Init(config *Config) (bool, error) {
 	if config == nil {
 		return false, kv.ErrNoConfig
 	}
-	s.cfg = config
+	if err := config.Validate(); err != nil {
+		return false, err
+	}
+	cfg := config.withDefaults()
+	s.cfg = &cfg
 	return true, nil
 }
 
+// Serve connects according to cfg.Mode: a plain *redis.Client in
+// ModeSingle, a Sentinel-aware *redis.Client in ModeSentinel, or a
+// *redis.ClusterClient in ModeCluster. Building the mode-specific client
+// directly (rather than go-redis's address-count heuristics in
+// NewUniversalClient) is what lets Mode pick the topology explicitly.
 func (s *Storage) Serve() error {
 	// init the wait group to prevent Serve to exit early, before RR called Stop
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 
-	options := &redis.UniversalOptions{
-		Addrs:    s.cfg.Addr,
-		DB:       s.cfg.Db,
-		Password: s.cfg.Password,
-		// The sentinel master name.
-		// Only failover clients.
-		MasterName: s.cfg.Master,
-	}
 	s.wg = wg
-	s.client = redis.NewUniversalClient(options)
+	s.client = s.cfg.newClient()
 
 	// Wait here
 	s.wg.Wait()
@@ -71,27 +116,34 @@ func (s Storage) Stop() {
 }
 
 // Has checks if value exists.
+//
+// Issues a single EXISTS per key, but batched through one pipeline
+// round-trip instead of one command per round-trip.
 func (s Storage) Has(ctx context.Context, keys ...string) (map[string]bool, error) {
 	if keys == nil {
 		return nil, kv.ErrNoKeys
 	}
-	m := make(map[string]bool, len(keys))
+
 	for _, key := range keys {
-		keyTrimmed := strings.TrimSpace(key)
-		if keyTrimmed == "" {
+		if strings.TrimSpace(key) == "" {
 			return nil, kv.ErrEmptyKey
 		}
+	}
 
-		exist, err := s.client.Exists(key).Result()
-		if err != nil {
-			return nil, err
-		}
-		switch exist {
-		case 0:
-			m[key] = false
-		case 1:
-			m[key] = true
+	cmds := make([]redis.Cmder, len(keys))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Exists(ctx, key)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, pipelineErr(keys, cmds, err)
+	}
+
+	m := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		m[key] = cmds[i].(*redis.IntCmd).Val() == 1
 	}
 	return m, nil
 }
@@ -103,12 +155,16 @@ func (s Storage) Get(ctx context.Context, key string) ([]byte, error) {
 	if keyTrimmed == "" {
 		return nil, kv.ErrEmptyKey
 	}
-	return s.client.Get(key).Bytes()
+	return s.client.Get(ctx, key).Bytes()
 }
 
 // MGet loads content of multiple values (some values might be skipped).
 // https://redis.io/commands/mget
 // Returns slice with the interfaces with values
+//
+// In ModeCluster, keys are grouped by hash slot and issued as one MGET per
+// slot, since a real Redis Cluster rejects a multi-key command whose keys
+// don't all map to the same slot with a CROSSSLOT error.
 func (s Storage) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
 	if keys == nil {
 		return nil, kv.ErrNoKeys
@@ -122,7 +178,11 @@ func (s Storage) MGet(ctx context.Context, keys ...string) ([]interface{}, error
 		}
 	}
 
-	slice := s.client.MGet(keys...)
+	if s.isCluster() {
+		return s.mgetBySlot(ctx, keys)
+	}
+
+	slice := s.client.MGet(ctx, keys...)
 	res, err := slice.Result()
 	if err != nil {
 		return nil, err
@@ -130,12 +190,36 @@ func (s Storage) MGet(ctx context.Context, keys ...string) ([]interface{}, error
 	return res, nil
 }
 
+// mgetBySlot implements MGet's ModeCluster path: one MGET per hash slot
+// represented in keys, stitched back into a single slice in keys' order.
+func (s Storage) mgetBySlot(ctx context.Context, keys []string) ([]interface{}, error) {
+	values := make(map[string]interface{}, len(keys))
+	for _, group := range groupBySlot(keys) {
+		res, err := s.client.MGet(ctx, group...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range group {
+			values[key] = res[i]
+		}
+	}
+
+	out := make([]interface{}, len(keys))
+	for i, key := range keys {
+		out[i] = values[key]
+	}
+	return out, nil
+}
+
 // Set sets value with the TTL in seconds
 // https://redis.io/commands/set
 // Redis `SET key value [expiration]` command.
 //
 // Use expiration for `SETEX`-like behavior.
 // Zero expiration means the key has no expiration time.
+//
+// Every item is SET within a single pipeline round-trip instead of one
+// SET per item.
 func (s Storage) Set(ctx context.Context, items ...kv.Item) error {
 	if items == nil {
 		return kv.ErrNoKeys
@@ -144,15 +228,28 @@ func (s Storage) Set(ctx context.Context, items ...kv.Item) error {
 		if item == kv.EmptyItem {
 			return kv.ErrEmptyItem
 		}
-		err := s.client.Set(item.Key, item.Value, time.Second*time.Duration(item.TTL)).Err()
-		if err != nil {
-			return err
+	}
+
+	keys := make([]string, len(items))
+	cmds := make([]redis.Cmder, len(items))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, item := range items {
+			keys[i] = item.Key
+			cmds[i] = pipe.Set(ctx, item.Key, item.Value, time.Second*time.Duration(item.TTL))
 		}
+		return nil
+	})
+	if err != nil {
+		return pipelineErr(keys, cmds, err)
 	}
+
 	return nil
 }
 
 // Delete one or multiple keys.
+//
+// In ModeCluster, keys are grouped by hash slot and issued as one DEL per
+// slot, for the same CROSSSLOT reason as MGet.
 func (s Storage) Delete(ctx context.Context, keys ...string) error {
 	if keys == nil {
 		return kv.ErrNoKeys
@@ -165,11 +262,24 @@ func (s Storage) Delete(ctx context.Context, keys ...string) error {
 			return kv.ErrEmptyKey
 		}
 	}
-	return s.client.Del(keys...).Err()
+
+	if s.isCluster() {
+		for _, group := range groupBySlot(keys) {
+			if err := s.client.Del(ctx, group...).Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return s.client.Del(ctx, keys...).Err()
 }
 
 // https://redis.io/commands/expire
 // timeout in seconds
+//
+// Every key is EXPIREd within a single pipeline round-trip instead of one
+// EXPIRE per key.
 func (s Storage) MExpire(ctx context.Context, timeout int, keys ...string) error {
 	if timeout == 0 || keys == nil {
 		return errors.New("should set timeout and at least one key")
@@ -177,8 +287,15 @@ func (s Storage) MExpire(ctx context.Context, timeout int, keys ...string) error
 
 	t := time.Duration(timeout) * time.Second
 
-	for _, key := range keys {
-		s.client.Expire(key, t)
+	cmds := make([]redis.Cmder, len(keys))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Expire(ctx, key, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return pipelineErr(keys, cmds, err)
 	}
 
 	return nil
@@ -186,6 +303,9 @@ func (s Storage) MExpire(ctx context.Context, timeout int, keys ...string) error
 
 // https://redis.io/commands/ttl
 // return time in seconds (float64) for a given keys
+//
+// Every key's TTL is fetched within a single pipeline round-trip instead of
+// one TTL per key.
 func (s Storage) TTL(ctx context.Context, keys ...string) (map[string]interface{}, error) {
 	if keys == nil {
 		return nil, kv.ErrNoKeys
@@ -199,15 +319,20 @@ func (s Storage) TTL(ctx context.Context, keys ...string) (map[string]interface{
 		}
 	}
 
-	m := make(map[string]interface{}, len(keys))
-
-	for _, key := range keys {
-		duration, err := s.client.TTL(key).Result()
-		if err != nil {
-			return nil, err
+	cmds := make([]redis.Cmder, len(keys))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.TTL(ctx, key)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, pipelineErr(keys, cmds, err)
+	}
 
-		m[key] = duration.Seconds()
+	m := make(map[string]interface{}, len(keys))
+	for i, key := range keys {
+		m[key] = cmds[i].(*redis.DurationCmd).Val().Seconds()
 	}
 	return m, nil
 }
@@ -215,4 +340,184 @@ func (s Storage) TTL(ctx context.Context, keys ...string) (map[string]interface{
 // Close closes the storage and underlying resources.
 func (s Storage) Close() error {
 	return s.client.Close()
-}
\ No newline at end of file
+}
+
+// Batch returns a Batch that applies every staged mutation inside a single
+// Redis MULTI/EXEC pipeline, so the batch is all-or-nothing.
+//
+// TODO unlike MGet/Delete, Commit doesn't group staged keys by hash slot,
+// so in ModeCluster a batch whose keys span more than one slot hits
+// CROSSSLOT. Fine for single-node/sentinel; revisit if cluster batches
+// are needed.
+func (s Storage) Batch() kv.Batch {
+	return &redisBatch{client: s.client}
+}
+
+type redisOpKind int
+
+const (
+	opSet redisOpKind = iota
+	opSetTTL
+	opDelete
+)
+
+type redisOp struct {
+	kind  redisOpKind
+	key   string
+	value string
+	ttl   int
+}
+
+// redisBatch stages writes/deletes and applies them through a single
+// TxPipeline on Commit.
+type redisBatch struct {
+	client redis.UniversalClient
+	ops    []redisOp
+}
+
+func (b *redisBatch) Set(key, value string, ttl int) kv.Batch {
+	b.ops = append(b.ops, redisOp{kind: opSet, key: key, value: value, ttl: ttl})
+	return b
+}
+
+func (b *redisBatch) SetTTL(key string, ttl int) kv.Batch {
+	b.ops = append(b.ops, redisOp{kind: opSetTTL, key: key, ttl: ttl})
+	return b
+}
+
+func (b *redisBatch) Delete(key string) kv.Batch {
+	b.ops = append(b.ops, redisOp{kind: opDelete, key: key})
+	return b
+}
+
+func (b *redisBatch) Commit(ctx context.Context) error {
+	ops := b.ops
+	b.ops = nil
+
+	pipe := b.client.TxPipeline()
+	for _, op := range ops {
+		switch op.kind {
+		case opDelete:
+			pipe.Del(ctx, op.key)
+		case opSetTTL:
+			pipe.Expire(ctx, op.key, time.Second*time.Duration(op.ttl))
+		default:
+			pipe.Set(ctx, op.key, op.value, time.Second*time.Duration(op.ttl))
+		}
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (b *redisBatch) Discard() {
+	b.ops = nil
+}
+
+// errClusterIterationUnsupported is returned by Iterator/ReverseIterator in
+// ModeCluster: client.Scan only walks whichever single node the cluster
+// client happens to route it to, not every master shard, so scanning ahead
+// would silently return a partial keyspace instead of an honest error.
+var errClusterIterationUnsupported = errors.New("kv/redis: Iterator/ReverseIterator not supported in cluster mode yet (requires a per-shard scan)")
+
+// Iterator returns an iterator over the keys in [start, end) in ascending
+// lexicographic order, backed by a SCAN cursor.
+//
+// TODO SCAN doesn't guarantee key order on the wire, so the whole matching
+// keyspace is buffered and sorted client-side before iteration starts. Fine
+// for the RoadRunner cache use case, but revisit if this needs to stream.
+func (s Storage) Iterator(ctx context.Context, start, end []byte) (kv.Iterator, error) {
+	return s.scanRange(ctx, start, end, false)
+}
+
+// ReverseIterator is like Iterator but walks keys in descending order.
+func (s Storage) ReverseIterator(ctx context.Context, start, end []byte) (kv.Iterator, error) {
+	return s.scanRange(ctx, start, end, true)
+}
+
+func (s Storage) scanRange(ctx context.Context, start, end []byte, reverse bool) (kv.Iterator, error) {
+	if s.isCluster() {
+		// TODO implement a per-shard scan (ClusterClient.ForEachMaster)
+		// and drop this guard once Iterator needs to support cluster mode.
+		return nil, errClusterIterationUnsupported
+	}
+
+	var keys []string
+	var cursor uint64
+	for {
+		batch, next, err := s.client.Scan(ctx, cursor, "", 100).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range batch {
+			if len(start) > 0 && key < string(start) {
+				continue
+			}
+			if len(end) > 0 && key >= string(end) {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	sort.Strings(keys)
+	if reverse {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	return &scanIterator{ctx: ctx, client: s.client, keys: keys}, nil
+}
+
+// scanIterator walks a pre-fetched, sorted slice of keys, fetching each
+// value lazily as the iterator advances.
+type scanIterator struct {
+	ctx    context.Context
+	client redis.UniversalClient
+	keys   []string
+	pos    int
+
+	key   []byte
+	value []byte
+	err   error
+}
+
+func (it *scanIterator) Valid() bool {
+	return it.err == nil && it.pos < len(it.keys)
+}
+
+func (it *scanIterator) Next() {
+	it.pos++
+}
+
+func (it *scanIterator) Key() []byte {
+	if !it.Valid() {
+		return nil
+	}
+	return []byte(it.keys[it.pos])
+}
+
+func (it *scanIterator) Value() []byte {
+	if !it.Valid() {
+		return nil
+	}
+	v, err := it.client.Get(it.ctx, it.keys[it.pos]).Bytes()
+	if err != nil {
+		it.err = err
+		return nil
+	}
+	return v
+}
+
+func (it *scanIterator) Error() error {
+	return it.err
+}
+
+func (it *scanIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/spiral/kv"
+	"testing"
+)
+
+// benchItems builds n distinct items so Set doesn't just overwrite the same
+// key on every iteration.
+func benchItems(n int) []kv.Item {
+	items := make([]kv.Item, n)
+	for i := range items {
+		items[i] = kv.Item{Key: fmt.Sprintf("bench-key-%d", i), Value: "hello world"}
+	}
+	return items
+}
+
+func benchKeys(items []kv.Item) []string {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.Key
+	}
+	return keys
+}
+
+// BenchmarkStorage_Set_Serial issues one SET per item, the old behavior.
+func BenchmarkStorage_Set_Serial(b *testing.B) {
+	s := initStorage()
+	defer s.Close()
+	ctx := context.Background()
+	items := benchItems(100)
+	defer func() { _ = s.Delete(ctx, benchKeys(items)...) }()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, item := range items {
+			if err := s.Set(ctx, item); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkStorage_Set_Pipelined issues every item's SET in one pipeline
+// round-trip.
+func BenchmarkStorage_Set_Pipelined(b *testing.B) {
+	s := initStorage()
+	defer s.Close()
+	ctx := context.Background()
+	items := benchItems(100)
+	defer func() { _ = s.Delete(ctx, benchKeys(items)...) }()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.Set(ctx, items...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStorage_TTL_Serial issues one TTL per key, the old behavior.
+func BenchmarkStorage_TTL_Serial(b *testing.B) {
+	s := initStorage()
+	defer s.Close()
+	ctx := context.Background()
+	items := benchItems(100)
+	keys := benchKeys(items)
+	if err := s.Set(ctx, items...); err != nil {
+		b.Fatal(err)
+	}
+	defer func() { _ = s.Delete(ctx, keys...) }()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, key := range keys {
+			if _, err := s.TTL(ctx, key); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkStorage_TTL_Pipelined issues every key's TTL in one pipeline
+// round-trip.
+func BenchmarkStorage_TTL_Pipelined(b *testing.B) {
+	s := initStorage()
+	defer s.Close()
+	ctx := context.Background()
+	items := benchItems(100)
+	keys := benchKeys(items)
+	if err := s.Set(ctx, items...); err != nil {
+		b.Fatal(err)
+	}
+	defer func() { _ = s.Delete(ctx, keys...) }()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.TTL(ctx, keys...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
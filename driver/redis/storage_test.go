@@ -2,7 +2,7 @@ package redis
 
 import (
 	"context"
-	"github.com/go-redis/redis/v7"
+	"github.com/go-redis/redis/v8"
 	"github.com/spiral/kv"
 	"github.com/stretchr/testify/assert"
 	"strconv"
@@ -350,3 +350,19 @@ func TestConcurrentReadWriteTransactions(t *testing.T) {
 
 	wg.Wait()
 }
+
+// TestIterator_ClusterModeReturnsError guards against Iterator/
+// ReverseIterator silently scanning a single cluster node and returning a
+// partial keyspace: in ModeCluster they must error instead. isCluster
+// returns on cfg.Mode before looking at s.client, so this doesn't need a
+// live connection.
+func TestIterator_ClusterModeReturnsError(t *testing.T) {
+	s := Storage{cfg: &Config{Mode: ModeCluster}}
+	ctx := context.Background()
+
+	_, err := s.Iterator(ctx, nil, nil)
+	assert.ErrorIs(t, err, errClusterIterationUnsupported)
+
+	_, err = s.ReverseIterator(ctx, nil, nil)
+	assert.ErrorIs(t, err, errClusterIterationUnsupported)
+}
@@ -0,0 +1,39 @@
+package sharded
+
+import (
+	"github.com/cespare/xxhash/v2"
+)
+
+// HashFunc scores a (key, node) pair for rendezvous hashing. Higher is
+// "more deserving" of owning the key.
+type HashFunc func(key, node string) uint64
+
+// defaultHash combines key and node with xxhash, the same hash used by
+// go-redis v8's ring client for its own rendezvous-style routing.
+func defaultHash(key, node string) uint64 {
+	h := xxhash.New()
+	_, _ = h.WriteString(key)
+	_, _ = h.WriteString("\x00")
+	_, _ = h.WriteString(node)
+	return h.Sum64()
+}
+
+// pickNode returns the id of the node with the highest score for key among
+// ids, per the rendezvous (highest-random-weight) algorithm: membership
+// changes only ever reassign the keys that hashed highest to the node that
+// left/joined, roughly 1/N of the keyspace, unlike consistent hashing's
+// ring gaps or a naive mod-N split.
+func pickNode(hash HashFunc, key string, ids []string) string {
+	var best string
+	var bestScore uint64
+
+	for i, id := range ids {
+		score := hash(key, id)
+		if i == 0 || score > bestScore {
+			best = id
+			bestScore = score
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,419 @@
+// Package sharded fans keys across N underlying kv.Storage instances using
+// rendezvous (highest-random-weight) hashing, so callers get horizontal
+// scaling of the in-memory backend, or can shard across independent
+// single-node Redis instances without needing Cluster mode.
+package sharded
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spiral/kv"
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrNoNodes is returned when every node is down (or none were added).
+var ErrNoNodes = errors.New("sharded: no live nodes")
+
+// Config controls hashing and health-check behavior.
+type Config struct {
+	// Hash scores a (key, node) pair. Defaults to xxhash-based rendezvous
+	// hashing when nil.
+	Hash HashFunc
+	// HealthCheckInterval is how often a down node is retried. Defaults
+	// to 5s.
+	HealthCheckInterval time.Duration
+	// MaxFailures is how many consecutive failures mark a node
+	// unhealthy. Defaults to 3.
+	MaxFailures int
+}
+
+func (c Config) withDefaults() Config {
+	if c.Hash == nil {
+		c.Hash = defaultHash
+	}
+	if c.HealthCheckInterval <= 0 {
+		c.HealthCheckInterval = 5 * time.Second
+	}
+	if c.MaxFailures <= 0 {
+		c.MaxFailures = 3
+	}
+	return c
+}
+
+type node struct {
+	id      string
+	storage kv.Storage
+
+	mu        sync.Mutex
+	failures  int
+	unhealthy bool
+	retryAt   time.Time
+}
+
+func (n *node) recordSuccess() {
+	n.mu.Lock()
+	n.failures = 0
+	n.unhealthy = false
+	n.mu.Unlock()
+}
+
+func (n *node) recordFailure(maxFailures int, backoff time.Duration) {
+	n.mu.Lock()
+	n.failures++
+	if n.failures >= maxFailures {
+		n.unhealthy = true
+		n.retryAt = time.Now().Add(backoff)
+	}
+	n.mu.Unlock()
+}
+
+// live reports whether the node should currently be considered for
+// routing: either it's healthy, or it's unhealthy but due for a retry
+// (allowing it to self-heal once the backend recovers).
+func (n *node) live() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return !n.unhealthy || !time.Now().Before(n.retryAt)
+}
+
+// Storage fans calls out to a set of kv.Storage nodes by rendezvous
+// hashing the key against each live node's id.
+type Storage struct {
+	cfg Config
+
+	mu    sync.RWMutex
+	nodes map[string]*node
+}
+
+// New returns an empty Storage; add backends with AddNode before use.
+func New(cfg Config) *Storage {
+	return &Storage{
+		cfg:   cfg.withDefaults(),
+		nodes: make(map[string]*node),
+	}
+}
+
+// AddNode registers (or replaces) a backend under id. Rendezvous hashing
+// means this only remaps the ~1/N keys that now hash highest to the new
+// node; it never reshuffles the whole keyspace.
+func (s *Storage) AddNode(id string, storage kv.Storage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[id] = &node{id: id, storage: storage}
+}
+
+// RemoveNode drops id from routing. The caller is responsible for closing
+// its underlying storage if desired.
+func (s *Storage) RemoveNode(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.nodes, id)
+}
+
+func (s *Storage) liveIDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.nodes))
+	for id, n := range s.nodes {
+		if n.live() {
+			ids = append(ids, id)
+		}
+	}
+	// deterministic ordering so pickNode's tie-breaking (first id wins
+	// ties) doesn't depend on map iteration order.
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *Storage) pick(key string) (*node, error) {
+	ids := s.liveIDs()
+	if len(ids) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	id := pickNode(s.cfg.Hash, key, ids)
+
+	s.mu.RLock()
+	n := s.nodes[id]
+	s.mu.RUnlock()
+
+	return n, nil
+}
+
+// groupByKey buckets keys by the node that owns them.
+func (s *Storage) groupByKey(keys []string) (map[*node][]string, error) {
+	groups := make(map[*node][]string)
+	for _, key := range keys {
+		n, err := s.pick(key)
+		if err != nil {
+			return nil, err
+		}
+		groups[n] = append(groups[n], key)
+	}
+	return groups, nil
+}
+
+// call invokes fn against n.storage and feeds the outcome into the node's
+// health tracking. A validation error (bad caller input, e.g.
+// kv.ErrEmptyKey/kv.ErrNoKeys) means n.storage was never actually
+// exercised, so it's reported as neither a success nor a failure —
+// otherwise bad client input would mark an otherwise-healthy node
+// unhealthy, and enough of it would exclude every node and return
+// ErrNoNodes to every caller.
+func (s *Storage) call(n *node, fn func(kv.Storage) error) error {
+	err := fn(n.storage)
+	if err != nil {
+		if !isValidationError(err) {
+			n.recordFailure(s.cfg.MaxFailures, s.cfg.HealthCheckInterval)
+		}
+		return err
+	}
+	n.recordSuccess()
+	return nil
+}
+
+// isValidationError reports whether err reflects bad caller input rather
+// than a real backend failure.
+func isValidationError(err error) bool {
+	return errors.Is(err, kv.ErrNoKeys) ||
+		errors.Is(err, kv.ErrEmptyKey) ||
+		errors.Is(err, kv.ErrNoConfig) ||
+		errors.Is(err, kv.ErrEmptyItem)
+}
+
+func (s *Storage) Has(ctx context.Context, keys ...string) (map[string]bool, error) {
+	if keys == nil {
+		return nil, kv.ErrNoKeys
+	}
+
+	groups, err := s.groupByKey(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	res := make(map[string]bool, len(keys))
+
+	errg, gctx := errgroup.WithContext(ctx)
+	for n, nodeKeys := range groups {
+		n, nodeKeys := n, nodeKeys
+		errg.Go(func() error {
+			return s.call(n, func(storage kv.Storage) error {
+				part, err := storage.Has(gctx, nodeKeys...)
+				if err != nil {
+					return err
+				}
+				mu.Lock()
+				for k, v := range part {
+					res[k] = v
+				}
+				mu.Unlock()
+				return nil
+			})
+		})
+	}
+
+	if err := errg.Wait(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	n, err := s.pick(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var value []byte
+	err = s.call(n, func(storage kv.Storage) error {
+		v, err := storage.Get(ctx, key)
+		value = v
+		return err
+	})
+	return value, err
+}
+
+// MGet groups keys by owning node and issues one concurrent call per node,
+// then merges results preserving the input key order.
+func (s *Storage) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if keys == nil {
+		return nil, kv.ErrNoKeys
+	}
+
+	// values are addressed by key so we can rebuild input order once every
+	// node has answered, even though each node only knows its own subset.
+	var mu sync.Mutex
+	byKey := make(map[string]interface{}, len(keys))
+
+	groups, err := s.groupByKey(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	errg, gctx := errgroup.WithContext(ctx)
+	for n, nodeKeys := range groups {
+		n, nodeKeys := n, nodeKeys
+		errg.Go(func() error {
+			return s.call(n, func(storage kv.Storage) error {
+				part, err := storage.MGet(gctx, nodeKeys...)
+				if err != nil {
+					return err
+				}
+				// MGet drops keys with no value, so we can only zip this
+				// node's own keys back onto its own results positionally.
+				mu.Lock()
+				for i, v := range part {
+					if i < len(nodeKeys) {
+						byKey[nodeKeys[i]] = v
+					}
+				}
+				mu.Unlock()
+				return nil
+			})
+		})
+	}
+
+	if err := errg.Wait(); err != nil {
+		return nil, err
+	}
+
+	res := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if v, ok := byKey[key]; ok {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func (s *Storage) Set(ctx context.Context, items ...kv.Item) error {
+	if items == nil {
+		return kv.ErrNoKeys
+	}
+
+	groups := make(map[*node][]kv.Item)
+	for _, item := range items {
+		n, err := s.pick(item.Key)
+		if err != nil {
+			return err
+		}
+		groups[n] = append(groups[n], item)
+	}
+
+	errg, gctx := errgroup.WithContext(ctx)
+	for n, nodeItems := range groups {
+		n, nodeItems := n, nodeItems
+		errg.Go(func() error {
+			return s.call(n, func(storage kv.Storage) error {
+				return storage.Set(gctx, nodeItems...)
+			})
+		})
+	}
+
+	return errg.Wait()
+}
+
+func (s *Storage) MExpire(ctx context.Context, timeout int, keys ...string) error {
+	if timeout == 0 || keys == nil {
+		return kv.ErrNoKeys
+	}
+
+	groups, err := s.groupByKey(keys)
+	if err != nil {
+		return err
+	}
+
+	errg, gctx := errgroup.WithContext(ctx)
+	for n, nodeKeys := range groups {
+		n, nodeKeys := n, nodeKeys
+		errg.Go(func() error {
+			return s.call(n, func(storage kv.Storage) error {
+				return storage.MExpire(gctx, timeout, nodeKeys...)
+			})
+		})
+	}
+
+	return errg.Wait()
+}
+
+func (s *Storage) TTL(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	if keys == nil {
+		return nil, kv.ErrNoKeys
+	}
+
+	groups, err := s.groupByKey(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	res := make(map[string]interface{}, len(keys))
+
+	errg, gctx := errgroup.WithContext(ctx)
+	for n, nodeKeys := range groups {
+		n, nodeKeys := n, nodeKeys
+		errg.Go(func() error {
+			return s.call(n, func(storage kv.Storage) error {
+				part, err := storage.TTL(gctx, nodeKeys...)
+				if err != nil {
+					return err
+				}
+				mu.Lock()
+				for k, v := range part {
+					res[k] = v
+				}
+				mu.Unlock()
+				return nil
+			})
+		})
+	}
+
+	if err := errg.Wait(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Delete groups keys by owning node and issues one concurrent call per
+// node.
+func (s *Storage) Delete(ctx context.Context, keys ...string) error {
+	if keys == nil {
+		return kv.ErrNoKeys
+	}
+
+	groups, err := s.groupByKey(keys)
+	if err != nil {
+		return err
+	}
+
+	errg, gctx := errgroup.WithContext(ctx)
+	for n, nodeKeys := range groups {
+		n, nodeKeys := n, nodeKeys
+		errg.Go(func() error {
+			return s.call(n, func(storage kv.Storage) error {
+				return storage.Delete(gctx, nodeKeys...)
+			})
+		})
+	}
+
+	return errg.Wait()
+}
+
+func (s *Storage) Close() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var firstErr error
+	for _, n := range s.nodes {
+		if err := n.storage.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,80 @@
+package sharded
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spiral/kv"
+	"github.com/stretchr/testify/assert"
+)
+
+// erroringStorage is a kv.Storage stub that always fails Get with a fixed
+// error, so call's classification of that error can be exercised directly.
+type erroringStorage struct {
+	err error
+}
+
+func (f *erroringStorage) Has(_ context.Context, _ ...string) (map[string]bool, error) {
+	return nil, f.err
+}
+
+func (f *erroringStorage) Get(_ context.Context, _ string) ([]byte, error) {
+	return nil, f.err
+}
+
+func (f *erroringStorage) MGet(_ context.Context, _ ...string) ([]interface{}, error) {
+	return nil, f.err
+}
+
+func (f *erroringStorage) Set(_ context.Context, _ ...kv.Item) error {
+	return f.err
+}
+
+func (f *erroringStorage) MExpire(_ context.Context, _ int, _ ...string) error {
+	return f.err
+}
+
+func (f *erroringStorage) TTL(_ context.Context, _ ...string) (map[string]interface{}, error) {
+	return nil, f.err
+}
+
+func (f *erroringStorage) Delete(_ context.Context, _ ...string) error {
+	return f.err
+}
+
+func (f *erroringStorage) Close() error {
+	return nil
+}
+
+// TestCall_ValidationErrorDoesNotMarkNodeUnhealthy guards against caller
+// input errors (kv.ErrEmptyKey) being forwarded into recordFailure, which
+// would mark an otherwise-healthy node unhealthy purely off client
+// mistakes, and eventually exclude every node and return ErrNoNodes to
+// every caller.
+func TestCall_ValidationErrorDoesNotMarkNodeUnhealthy(t *testing.T) {
+	s := New(Config{MaxFailures: 1})
+	s.AddNode("a", &erroringStorage{err: kv.ErrEmptyKey})
+
+	for i := 0; i < 10; i++ {
+		_, err := s.Get(context.Background(), "key")
+		assert.ErrorIs(t, err, kv.ErrEmptyKey)
+	}
+
+	n := s.nodes["a"]
+	assert.True(t, n.live())
+}
+
+// TestCall_BackendErrorMarksNodeUnhealthy is the control: a real backend
+// failure still marks the node unhealthy after MaxFailures.
+func TestCall_BackendErrorMarksNodeUnhealthy(t *testing.T) {
+	s := New(Config{MaxFailures: 1, HealthCheckInterval: time.Hour})
+	s.AddNode("a", &erroringStorage{err: errors.New("connection refused")})
+
+	_, err := s.Get(context.Background(), "key")
+	assert.Error(t, err)
+
+	n := s.nodes["a"]
+	assert.False(t, n.live())
+}
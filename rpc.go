@@ -2,13 +2,58 @@ package kv
 
 import (
 	"context"
+	"time"
 
 	"github.com/spiral/kv/buffer/data"
 	"golang.org/x/sync/errgroup"
 )
 
+// defaultRPCTimeout bounds a handler's derived context when RpcServer.Timeout
+// is unset.
+const defaultRPCTimeout = 5 * time.Second
+
 type RpcServer struct {
 	svc *Service
+
+	// Timeout bounds the context derived for each RPC call. Defaults to
+	// defaultRPCTimeout when zero.
+	Timeout time.Duration
+
+	// stop, when non-nil, is closed by Shutdown to cancel every in-flight
+	// handler context. Nothing in this package sets it, so it is nil on a
+	// plain RpcServer value and handler contexts then end only on Timeout.
+	stop chan struct{}
+}
+
+// newCtx derives a context for a single RPC call, bounded by both
+// r.Timeout and the server's lifetime: it's cancelled on timeout or on
+// Shutdown, whichever comes first.
+func (r *RpcServer) newCtx() (context.Context, context.CancelFunc) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = defaultRPCTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	if r.stop != nil {
+		go func() {
+			select {
+			case <-r.stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return ctx, cancel
+}
+
+// Shutdown closes stop, if set, cancelling every handler context still in
+// flight; with a nil stop it does nothing.
+func (r *RpcServer) Shutdown() {
+	if r.stop != nil {
+		close(r.stop)
+	}
 }
 
 type Data struct {
@@ -19,7 +64,8 @@ type Data struct {
 
 // data Data
 func (r *RpcServer) Has(in []byte, res *map[string]bool) error {
-	ctx := context.Background()
+	ctx, cancel := r.newCtx()
+	defer cancel()
 	dataRoot := data.GetRootAsData(in, 0)
 	l := dataRoot.KeysLength()
 	keys := make([]string, 0, l)
@@ -48,7 +94,8 @@ type SetData struct {
 
 // in SetData
 func (r *RpcServer) Set(in []byte, ok *bool) error {
-	ctx := context.Background()
+	ctx, cancel := r.newCtx()
+	defer cancel()
 	dataRoot := data.GetRootAsSetData(in, 0)
 
 	items := make([]Item, 0, dataRoot.ItemsLength())
@@ -83,7 +130,8 @@ func (r *RpcServer) Set(in []byte, ok *bool) error {
 
 // in Data
 func (r *RpcServer) Get(in []byte, res *[]byte) error {
-	ctx := context.Background()
+	ctx, cancel := r.newCtx()
+	defer cancel()
 	dataRoot := data.GetRootAsData(in, 0)
 	l := dataRoot.KeysLength()
 	keys := make([]string, 0, l)
@@ -107,7 +155,8 @@ func (r *RpcServer) Get(in []byte, res *[]byte) error {
 
 // in Data
 func (r *RpcServer) MGet(in []byte, res *map[string]interface{}) error {
-	ctx := context.Background()
+	ctx, cancel := r.newCtx()
+	defer cancel()
 	dataRoot := data.GetRootAsData(in, 0)
 	l := dataRoot.KeysLength()
 	keys := make([]string, 0, l)
@@ -131,7 +180,8 @@ func (r *RpcServer) MGet(in []byte, res *map[string]interface{}) error {
 
 // in Data
 func (r *RpcServer) MExpire(in []byte, ok *bool) error {
-	ctx := context.Background()
+	ctx, cancel := r.newCtx()
+	defer cancel()
 	dataRoot := data.GetRootAsData(in, 0)
 	l := dataRoot.KeysLength()
 
@@ -159,7 +209,8 @@ func (r *RpcServer) MExpire(in []byte, ok *bool) error {
 
 // in Data
 func (r *RpcServer) TTL(in []byte, res *map[string]interface{}) error {
-	ctx := context.Background()
+	ctx, cancel := r.newCtx()
+	defer cancel()
 	dataRoot := data.GetRootAsData(in, 0)
 	l := dataRoot.KeysLength()
 	keys := make([]string, 0, l)
@@ -183,7 +234,8 @@ func (r *RpcServer) TTL(in []byte, res *map[string]interface{}) error {
 
 // in Data
 func (r *RpcServer) Delete(in []byte, ok *bool) error {
-	ctx := context.Background()
+	ctx, cancel := r.newCtx()
+	defer cancel()
 	dataRoot := data.GetRootAsData(in, 0)
 	l := dataRoot.KeysLength()
 	keys := make([]string, 0, l)
@@ -205,6 +257,36 @@ func (r *RpcServer) Delete(in []byte, ok *bool) error {
 	return nil
 }
 
+// invalidator is implemented by storages (e.g. layered.Storage) whose L1
+// cache needs to be told about writes that happened on another node.
+type invalidator interface {
+	InvalidateKeys(keys ...string)
+}
+
+// in Data
+// Invalidate drops the given keys from a storage's local L1 cache, without
+// touching L2. Used to keep a cluster of RoadRunner nodes running a
+// layered.Storage coherent: a write on one node calls this RPC on the
+// others instead of relying on L1 TTLs alone.
+func (r *RpcServer) Invalidate(in []byte, ok *bool) error {
+	dataRoot := data.GetRootAsData(in, 0)
+	l := dataRoot.KeysLength()
+	keys := make([]string, 0, l)
+
+	for i := 0; i < l; i++ {
+		keys = append(keys, string(dataRoot.Keys(i)))
+	}
+
+	storage := string(dataRoot.Storage())
+
+	if inv, isInvalidator := r.svc.Storages[storage].(invalidator); isInvalidator {
+		inv.InvalidateKeys(keys...)
+	}
+
+	*ok = true
+	return nil
+}
+
 // in string, storages
 func (r *RpcServer) Close(storage string, ok *bool) error {
 	err := r.svc.Storages[storage].Close()